@@ -0,0 +1,23 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Like, rechirp and reply counts are kept up to date by the transactions that change them.
+// They are recomputed from the underlying rows daily to repair any drift.
+const counterRecomputeInterval = 24 * time.Hour
+
+// Method to recompute every chirp's like, rechirp and reply counts
+func (cfg *apiConfig) runCounterRecomputeJob(ctx context.Context, _ struct{}) error {
+	fixed, err := cfg.db.RecomputeChirpCounters(ctx)
+	if err != nil {
+		return err
+	}
+	if fixed > 0 {
+		log.Printf("Corrected engagement counts on %d chirps", fixed)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"chirpy/internal/auth"
+	"chirpy/internal/database"
+	chirpymail "chirpy/internal/mail"
+
+	"github.com/google/uuid"
+)
+
+// Lifetimes of tokens sent by email
+const (
+	emailVerificationExpiresIn = 24 * time.Hour
+	passwordResetExpiresIn     = time.Hour
+)
+
+// Function to validate an email address, returning it without surrounding whitespace
+func parseEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", errors.New("Invalid email address")
+	}
+	return email, nil
+}
+
+// Method to queue a message to be sent by a background job, which retries failed deliveries.
+// Only messages without secrets are queued as they are, since job payloads are stored.
+func (cfg *apiConfig) queueEmail(ctx context.Context, msg chirpymail.Message) error {
+	return cfg.enqueueJob(ctx, jobKindSendEmail, msg, time.Now(), "")
+}
+
+// Method to queue a verification link for a user's current address. The token is only
+// issued when the job runs, so it never sits in the job queue.
+func (cfg *apiConfig) queueEmailVerification(ctx context.Context, userID uuid.UUID) error {
+	return cfg.enqueueJob(ctx, jobKindEmailVerification, userJobPayload{UserID: userID}, time.Now(), "")
+}
+
+// Method to queue a password reset token for a user, issued when the job runs
+func (cfg *apiConfig) queuePasswordReset(ctx context.Context, userID uuid.UUID) error {
+	return cfg.enqueueJob(ctx, jobKindPasswordReset, userJobPayload{UserID: userID}, time.Now(), "")
+}
+
+// Method to create a single-use token for a user and email it to them. Earlier tokens for
+// the same purpose stop working.
+func (cfg *apiConfig) issueEmailToken(ctx context.Context, user database.User, purpose string, expiresIn time.Duration) (string, error) {
+	token, err := auth.MakeEmailToken()
+	if err != nil {
+		return "", err
+	}
+	err = cfg.db.ReplaceEmailTokenTx(ctx, database.CreateEmailTokenParams{
+		TokenHash: auth.HashEmailToken(token),
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Email:     user.Email,
+		ExpiresAt: time.Now().UTC().Add(expiresIn),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Method to email a user a link that verifies their current address. Run by the email
+// verification job.
+func (cfg *apiConfig) sendEmailVerification(ctx context.Context, user database.User) error {
+	token, err := cfg.issueEmailToken(ctx, user, database.EmailTokenPurposeVerify, emailVerificationExpiresIn)
+	if err != nil {
+		return err
+	}
+	link := cfg.publicURL + "/api/users/verify-email?token=" + url.QueryEscape(token)
+	return cfg.mailer.Send(ctx, chirpymail.Message{
+		To:      user.Email,
+		Subject: "Verify your Chirpy email address",
+		Body: fmt.Sprintf("Confirm this is your email address by opening the link below within %d hours:\n\n%s\n\n"+
+			"If you didn't sign up for Chirpy or change your email, you can ignore this message.",
+			int(emailVerificationExpiresIn.Hours()), link),
+	})
+}
+
+// Method to email a user a token to reset their password. Run by the password reset job.
+func (cfg *apiConfig) sendPasswordReset(ctx context.Context, user database.User) error {
+	token, err := cfg.issueEmailToken(ctx, user, database.EmailTokenPurposePasswordReset, passwordResetExpiresIn)
+	if err != nil {
+		return err
+	}
+	return cfg.mailer.Send(ctx, chirpymail.Message{
+		To:      user.Email,
+		Subject: "Reset your Chirpy password",
+		Body: fmt.Sprintf("Someone asked to reset the password for your Chirpy account. Your reset token is:\n\n%s\n\n"+
+			"It works once and expires in %d minutes. Resetting your password logs you out everywhere.\n\n"+
+			"If you didn't ask for this, you can ignore this message and your password won't change.",
+			token, int(passwordResetExpiresIn.Minutes())),
+	})
+}
@@ -0,0 +1,439 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"chirpy/internal/auth"
+	"chirpy/internal/database"
+	"chirpy/internal/search"
+
+	"github.com/google/uuid"
+)
+
+// Struct to contain user information for admins, including account state hidden from
+// other users
+type AdminUser struct {
+	ID                    uuid.UUID  `json:"id"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+	Email                 string     `json:"email"`
+	EmailVerified         bool       `json:"email_verified"`
+	Handle                string     `json:"handle,omitempty"`
+	DisplayName           string     `json:"display_name,omitempty"`
+	Role                  string     `json:"role"`
+	IsChirpyRed           bool       `json:"is_chirpy_red"`
+	SuspendedAt           *time.Time `json:"suspended_at"`
+	PasswordResetRequired bool       `json:"password_reset_required"`
+}
+
+// Function to convert a database user to its admin JSON form
+func adminUserFromDB(dbUser database.User) AdminUser {
+	user := AdminUser{
+		ID:                    dbUser.ID,
+		CreatedAt:             dbUser.CreatedAt,
+		UpdatedAt:             dbUser.UpdatedAt,
+		Email:                 dbUser.Email,
+		EmailVerified:         dbUser.EmailVerifiedAt.Valid,
+		Handle:                dbUser.Handle.String,
+		DisplayName:           dbUser.DisplayName.String,
+		Role:                  dbUser.Role,
+		IsChirpyRed:           dbUser.IsChirpyRed,
+		PasswordResetRequired: dbUser.PasswordResetRequired,
+	}
+	if dbUser.SuspendedAt.Valid {
+		user.SuspendedAt = &dbUser.SuspendedAt.Time
+	}
+	return user
+}
+
+// Struct to contain an audit log entry
+type AuditLogEntry struct {
+	ID         uuid.UUID       `json:"id"`
+	CreatedAt  time.Time       `json:"created_at"`
+	ActorID    *uuid.UUID      `json:"actor_id"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   *uuid.UUID      `json:"target_id"`
+	Details    json.RawMessage `json:"details"`
+}
+
+// Function to gather the admin performing a request, with an optional reason from the
+// JSON body. An empty body is allowed.
+func auditActorFrom(r *http.Request) (database.AuditActor, error) {
+
+	// Struct for JSON request parameters
+	type parameters struct {
+		Reason string `json:"reason"`
+	}
+
+	params := parameters{}
+	err := json.NewDecoder(r.Body).Decode(&params)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return database.AuditActor{}, err
+	}
+	return database.AuditActor{
+		ID:     principalFrom(r).UserID,
+		Reason: strings.TrimSpace(params.Reason),
+	}, nil
+}
+
+// Function to gather and validate the user ID path value of an admin request
+func adminTargetUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// Handler function to list users for admins, filtered by an email or handle prefix, role
+// and suspension
+func (cfg *apiConfig) handlerAdminUsersGet(w http.ResponseWriter, r *http.Request) {
+
+	// Struct for paginated JSON response
+	type response struct {
+		Users      []AdminUser `json:"users"`
+		NextCursor *string     `json:"next_cursor"`
+	}
+
+	// Gather and validate limit and cursor parameters
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	// Gather optional filters
+	query := r.URL.Query()
+	arg := database.AdminListUsersParams{
+		AfterCreatedAt: page.afterCreatedAt(),
+		AfterID:        page.afterID(),
+		PageSize:       page.fetchSize(),
+	}
+	if q := query.Get("q"); strings.TrimPrefix(strings.TrimSpace(q), "@") != "" {
+		arg.Pattern = sql.NullString{String: search.PrefixPattern(q), Valid: true}
+	}
+	if role := query.Get("role"); role != "" {
+		if _, ok := roleRanks[role]; !ok {
+			respondWithError(w, http.StatusBadRequest, "Invalid role", nil)
+			return
+		}
+		arg.Role = sql.NullString{String: role, Valid: true}
+	}
+	if suspended := query.Get("suspended"); suspended != "" {
+		b, err := strconv.ParseBool(suspended)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid suspended filter", err)
+			return
+		}
+		arg.Suspended = sql.NullBool{Bool: b, Valid: true}
+	}
+
+	dbUsers, err := cfg.db.AdminListUsers(r.Context(), arg)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retreive users", err)
+		return
+	}
+
+	// If an extra row was returned there is another page
+	var nextCursor *string
+	if len(dbUsers) > int(page.Limit) {
+		dbUsers = dbUsers[:page.Limit]
+		last := dbUsers[len(dbUsers)-1]
+		cursor := encodeCursor(last.CreatedAt, last.ID)
+		nextCursor = &cursor
+	}
+
+	users := []AdminUser{}
+	for _, dbUser := range dbUsers {
+		users = append(users, adminUserFromDB(dbUser))
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Users:      users,
+		NextCursor: nextCursor,
+	})
+}
+
+// Handler function to get a single user for admins
+func (cfg *apiConfig) handlerAdminUserGet(w http.ResponseWriter, r *http.Request) {
+	userID, ok := adminTargetUserID(w, r)
+	if !ok {
+		return
+	}
+
+	dbUser, err := cfg.db.GetUser(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, adminUserFromDB(dbUser))
+}
+
+// Handler function to suspend a user, logging them out everywhere
+func (cfg *apiConfig) handlerAdminUserSuspend(w http.ResponseWriter, r *http.Request) {
+	userID, ok := adminTargetUserID(w, r)
+	if !ok {
+		return
+	}
+
+	actor, err := auditActorFrom(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	if actor.ID == userID {
+		respondWithError(w, http.StatusConflict, "You can't suspend yourself", nil)
+		return
+	}
+
+	// Access tokens issued up to now stop working, as with logging out everywhere
+	validAfter := auth.RevocationCutoff(time.Now())
+	dbUser, err := cfg.db.SuspendUserTx(r.Context(), actor, userID, validAfter)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't suspend user", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, adminUserFromDB(dbUser))
+}
+
+// Handler function to lift a user's suspension
+func (cfg *apiConfig) handlerAdminUserUnsuspend(w http.ResponseWriter, r *http.Request) {
+	userID, ok := adminTargetUserID(w, r)
+	if !ok {
+		return
+	}
+
+	actor, err := auditActorFrom(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	dbUser, err := cfg.db.UnsuspendUserTx(r.Context(), actor, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unsuspend user", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, adminUserFromDB(dbUser))
+}
+
+// Handler function to make a user reset their password before logging in again. They are
+// logged out everywhere and emailed a reset token.
+func (cfg *apiConfig) handlerAdminUserForcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	userID, ok := adminTargetUserID(w, r)
+	if !ok {
+		return
+	}
+
+	actor, err := auditActorFrom(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	validAfter := auth.RevocationCutoff(time.Now())
+	dbUser, err := cfg.db.ForcePasswordResetTx(r.Context(), actor, userID, validAfter)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't require password reset", err)
+		return
+	}
+
+	err = cfg.queuePasswordReset(r.Context(), dbUser.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't send password reset", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, adminUserFromDB(dbUser))
+}
+
+// Handler function to change a user's role
+func (cfg *apiConfig) handlerAdminUserRole(w http.ResponseWriter, r *http.Request) {
+
+	// Struct for JSON request parameters
+	type parameters struct {
+		Role   string `json:"role"`
+		Reason string `json:"reason"`
+	}
+
+	userID, ok := adminTargetUserID(w, r)
+	if !ok {
+		return
+	}
+
+	// Decode JSON and gather parameters
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	if _, ok := roleRanks[params.Role]; !ok {
+		respondWithError(w, http.StatusBadRequest, "Role must be user, moderator or admin", nil)
+		return
+	}
+
+	// Admins can't demote themselves, so there is always at least one admin
+	actor := database.AuditActor{
+		ID:     principalFrom(r).UserID,
+		Reason: strings.TrimSpace(params.Reason),
+	}
+	if actor.ID == userID && params.Role != database.RoleAdmin {
+		respondWithError(w, http.StatusConflict, "You can't remove your own admin role", nil)
+		return
+	}
+
+	dbUser, err := cfg.db.SetUserRoleTx(r.Context(), actor, userID, params.Role)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't change role", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, adminUserFromDB(dbUser))
+}
+
+// Handler function for moderators to delete any user's chirp. A reason may be given in the
+// query string.
+func (cfg *apiConfig) handlerAdminChirpsDelete(w http.ResponseWriter, r *http.Request) {
+
+	// Validate chirp ID is found
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	actor := database.AuditActor{
+		ID:     principalFrom(r).UserID,
+		Reason: strings.TrimSpace(r.URL.Query().Get("reason")),
+	}
+
+	// Delete chirp from database (leaving a tombstone if it has replies)
+	err = cfg.db.AdminDeleteChirpTx(r.Context(), actor, chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't get chirp", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete chirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Handler function to page through the audit log, newest first, optionally filtered by
+// actor, target and action
+func (cfg *apiConfig) handlerAdminAuditLog(w http.ResponseWriter, r *http.Request) {
+
+	// Struct for paginated JSON response
+	type response struct {
+		Entries    []AuditLogEntry `json:"entries"`
+		NextCursor *string         `json:"next_cursor"`
+	}
+
+	// Gather and validate limit and cursor parameters
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	// Gather optional filters
+	query := r.URL.Query()
+	arg := database.ListAuditLogParams{
+		AfterCreatedAt: page.afterCreatedAt(),
+		AfterID:        page.afterID(),
+		PageSize:       page.fetchSize(),
+	}
+	if actorID := query.Get("actor_id"); actorID != "" {
+		id, err := uuid.Parse(actorID)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid actor ID", err)
+			return
+		}
+		arg.ActorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	if targetID := query.Get("target_id"); targetID != "" {
+		id, err := uuid.Parse(targetID)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid target ID", err)
+			return
+		}
+		arg.TargetID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	if action := query.Get("action"); action != "" {
+		arg.Action = sql.NullString{String: action, Valid: true}
+	}
+
+	rows, err := cfg.db.ListAuditLog(r.Context(), arg)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retreive audit log", err)
+		return
+	}
+
+	// If an extra row was returned there is another page
+	var nextCursor *string
+	if len(rows) > int(page.Limit) {
+		rows = rows[:page.Limit]
+		last := rows[len(rows)-1]
+		cursor := encodeCursor(last.CreatedAt, last.ID)
+		nextCursor = &cursor
+	}
+
+	entries := []AuditLogEntry{}
+	for _, row := range rows {
+		entry := AuditLogEntry{
+			ID:         row.ID,
+			CreatedAt:  row.CreatedAt,
+			Action:     row.Action,
+			TargetType: row.TargetType,
+			Details:    row.Details,
+		}
+		if row.ActorID.Valid {
+			entry.ActorID = &row.ActorID.UUID
+		}
+		if row.TargetID.Valid {
+			entry.TargetID = &row.TargetID.UUID
+		}
+		entries = append(entries, entry)
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Entries:    entries,
+		NextCursor: nextCursor,
+	})
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Struct to contain a background job for admins
+type Job struct {
+	ID          uuid.UUID       `json:"id"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int32           `json:"attempts"`
+	MaxAttempts int32           `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	LockedUntil *time.Time      `json:"locked_until"`
+	LastError   *string         `json:"last_error"`
+	FinishedAt  *time.Time      `json:"finished_at"`
+}
+
+// Function to convert a database job to its JSON form
+func jobFromDB(dbJob database.Job) Job {
+	job := Job{
+		ID:          dbJob.ID,
+		CreatedAt:   dbJob.CreatedAt,
+		UpdatedAt:   dbJob.UpdatedAt,
+		Kind:        dbJob.Kind,
+		Payload:     dbJob.Payload,
+		Status:      dbJob.Status,
+		Attempts:    dbJob.Attempts,
+		MaxAttempts: dbJob.MaxAttempts,
+		RunAt:       dbJob.RunAt,
+	}
+	if dbJob.LockedUntil.Valid {
+		job.LockedUntil = &dbJob.LockedUntil.Time
+	}
+	if dbJob.LastError.Valid {
+		job.LastError = &dbJob.LastError.String
+	}
+	if dbJob.FinishedAt.Valid {
+		job.FinishedAt = &dbJob.FinishedAt.Time
+	}
+	return job
+}
+
+// Function to gather and validate the job ID path value of an admin request
+func adminTargetJobID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	jobID, err := uuid.Parse(r.PathValue("jobID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid job ID", err)
+		return uuid.Nil, false
+	}
+	return jobID, true
+}
+
+// Handler function to page through background jobs, newest first, optionally filtered by
+// status and kind
+func (cfg *apiConfig) handlerAdminJobsGet(w http.ResponseWriter, r *http.Request) {
+
+	// Struct for paginated JSON response
+	type response struct {
+		Jobs       []Job   `json:"jobs"`
+		NextCursor *string `json:"next_cursor"`
+	}
+
+	// Gather and validate limit and cursor parameters
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	// Gather optional filters
+	query := r.URL.Query()
+	arg := database.ListJobsParams{
+		AfterCreatedAt: page.afterCreatedAt(),
+		AfterID:        page.afterID(),
+		PageSize:       page.fetchSize(),
+	}
+	if status := query.Get("status"); status != "" {
+		statuses := []string{database.JobStatusPending, database.JobStatusRunning, database.JobStatusSucceeded, database.JobStatusDead}
+		if !slices.Contains(statuses, status) {
+			respondWithError(w, http.StatusBadRequest, "Invalid status", nil)
+			return
+		}
+		arg.Status = sql.NullString{String: status, Valid: true}
+	}
+	if kind := query.Get("kind"); kind != "" {
+		arg.Kind = sql.NullString{String: kind, Valid: true}
+	}
+
+	dbJobs, err := cfg.db.ListJobs(r.Context(), arg)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retreive jobs", err)
+		return
+	}
+
+	// If an extra row was returned there is another page
+	var nextCursor *string
+	if len(dbJobs) > int(page.Limit) {
+		dbJobs = dbJobs[:page.Limit]
+		last := dbJobs[len(dbJobs)-1]
+		cursor := encodeCursor(last.CreatedAt, last.ID)
+		nextCursor = &cursor
+	}
+
+	jobs := []Job{}
+	for _, dbJob := range dbJobs {
+		jobs = append(jobs, jobFromDB(dbJob))
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Jobs:       jobs,
+		NextCursor: nextCursor,
+	})
+}
+
+// Handler function to get one background job
+func (cfg *apiConfig) handlerAdminJobGet(w http.ResponseWriter, r *http.Request) {
+	jobID, ok := adminTargetJobID(w, r)
+	if !ok {
+		return
+	}
+
+	dbJob, err := cfg.db.GetJob(r.Context(), jobID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find job", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retreive job", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, jobFromDB(dbJob))
+}
+
+// Handler function to put a dead job back in the queue with a fresh set of attempts
+func (cfg *apiConfig) handlerAdminJobRetry(w http.ResponseWriter, r *http.Request) {
+	jobID, ok := adminTargetJobID(w, r)
+	if !ok {
+		return
+	}
+	actor, err := auditActorFrom(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	dbJob, err := cfg.db.RetryDeadJobTx(r.Context(), actor, jobID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find job", err)
+		return
+	}
+	if errors.Is(err, database.ErrJobNotDead) {
+		respondWithError(w, http.StatusConflict, "Only dead jobs can be retried", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retry job", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, jobFromDB(dbJob))
+}
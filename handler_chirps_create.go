@@ -1,25 +1,76 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"strings"
 	"time"
 
-	"chirpy/internal/auth"
 	"chirpy/internal/database"
+	"chirpy/internal/entities"
 
 	"github.com/google/uuid"
 )
 
 // Struct for chirp to be stored in database
 type Chirp struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	UserID    uuid.UUID `json:"user_id"`
-	Body      string    `json:"body"`
+	ID           uuid.UUID     `json:"id"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+	UserID       uuid.UUID     `json:"user_id"`
+	Body         string        `json:"body"`
+	InReplyTo    *uuid.UUID    `json:"in_reply_to"`
+	ReplyCount   int32         `json:"reply_count"`
+	LikeCount    int32         `json:"like_count"`
+	RechirpCount int32         `json:"rechirp_count"`
+	LikedByMe    *bool         `json:"liked_by_me,omitempty"`
+	RechirpedBy  *uuid.UUID    `json:"rechirped_by,omitempty"`
+	Edited       bool          `json:"edited"`
+	Deleted      bool          `json:"deleted,omitempty"`
+	Entities     ChirpEntities `json:"entities"`
+}
+
+// Struct for structured entities parsed from a chirp body
+type ChirpEntities struct {
+	Hashtags []string        `json:"hashtags"`
+	Mentions []MentionEntity `json:"mentions"`
+}
+
+// Struct for an @mention resolved to a user. Offsets are in Unicode code points,
+// with start at the '@' and end just past the handle.
+type MentionEntity struct {
+	UserID uuid.UUID `json:"user_id"`
+	Handle string    `json:"handle"`
+	Start  int32     `json:"start"`
+	End    int32     `json:"end"`
+}
+
+// Function to convert a database chirp into its JSON representation
+func chirpFromDB(dbChirp database.Chirp) Chirp {
+	chirp := Chirp{
+		ID:           dbChirp.ID,
+		CreatedAt:    dbChirp.CreatedAt,
+		UpdatedAt:    dbChirp.UpdatedAt,
+		UserID:       dbChirp.UserID,
+		Body:         dbChirp.Body,
+		ReplyCount:   dbChirp.ReplyCount,
+		LikeCount:    dbChirp.LikeCount,
+		RechirpCount: dbChirp.RechirpCount,
+		Edited:       dbChirp.EditedAt.Valid,
+		Deleted:      dbChirp.DeletedAt.Valid,
+		Entities: ChirpEntities{
+			Hashtags: entities.Hashtags(dbChirp.Body),
+			Mentions: []MentionEntity{},
+		},
+	}
+	if dbChirp.InReplyTo.Valid {
+		inReplyTo := dbChirp.InReplyTo.UUID
+		chirp.InReplyTo = &inReplyTo
+	}
+	return chirp
 }
 
 // Handler function to validate and create chirps
@@ -27,62 +78,80 @@ func (cfg *apiConfig) handlerChirpsCreate(w http.ResponseWriter, r *http.Request
 
 	// Setup struct for expected JSON parameters
 	type parameters struct {
-		Body string `json:"body"`
+		Body      string     `json:"body"`
+		InReplyTo *uuid.UUID `json:"in_reply_to"`
 	}
 
-	// Gather and validate JWT bearer token to generate UserID
-	token, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
-		return
-	}
-	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
-		return
-	}
+	// Gather UserID of principal authenticated by middleware
+	userID := principalFrom(r).UserID
 
 	// Decode JSON and gather parameters
 	decoder := json.NewDecoder(r.Body)
 	params := parameters{}
-	err = decoder.Decode(&params)
+	err := decoder.Decode(&params)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
 		return
 	}
 
+	// Gather the user's plan limits, which set the longest chirp they can post
+	limits, err := cfg.limitsFor(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get plan limits", err)
+		return
+	}
+
 	// Call function to validate chirp body
-	cleaned, err := validateChirp(params.Body)
+	cleaned, err := validateChirp(params.Body, limits.MaxChirpLength)
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, err.Error(), err)
 		return
 	}
 
-	// Create chirp in database
-	chirp, err := cfg.db.CreateChirp(r.Context(), database.CreateChirpParams{
-		Body:   cleaned,
-		UserID: userID,
-	})
+	// Gather chirp being replied to if provided
+	inReplyTo := uuid.NullUUID{}
+	if params.InReplyTo != nil {
+		inReplyTo = uuid.NullUUID{UUID: *params.InReplyTo, Valid: true}
+	}
+
+	// Parse hashtags and mentions from chirp body
+	ents, err := cfg.parseChirpEntities(r.Context(), cleaned)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't resolve mentions", err)
+		return
+	}
+
+	// Create chirp in database along with its hashtags and mentions
+	chirp, err := cfg.db.CreateChirpTx(r.Context(), database.CreateChirpParams{
+		Body:      cleaned,
+		UserID:    userID,
+		InReplyTo: inReplyTo,
+	}, ents)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find chirp to reply to", err)
+			return
+		}
 		respondWithError(w, http.StatusInternalServerError, "Couldn't create chirp", err)
 		return
 	}
 
+	// Render mentions as structured entities
+	chirps := []Chirp{chirpFromDB(chirp)}
+	err = cfg.attachMentions(r.Context(), chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retreive mentions", err)
+		return
+	}
+
 	// If chirp is valid, respond with 201 status code and full chirp resource
-	respondWithJSON(w, http.StatusCreated, Chirp{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
-	})
+	respondWithJSON(w, http.StatusCreated, chirps[0])
 }
 
 // Function to validate chirp length and content
-func validateChirp(body string) (string, error) {
+func validateChirp(body string, maxChirpLength int) (string, error) {
 
-	// Validate chirp length is within limit - if not, respond with error
-	const maxChirpLength = 140
+	// Validate chirp length is within the plan's limit - if not, respond with error
 	if len(body) > maxChirpLength {
 		return "", errors.New("Chirp is too long")
 
@@ -102,6 +171,46 @@ func validateChirp(body string) (string, error) {
 	return cleaned, nil
 }
 
+// Method to parse hashtags and @mentions from a validated chirp body. Mentions are
+// resolved against user handles; handles that don't belong to anyone are left as text.
+func (cfg *apiConfig) parseChirpEntities(ctx context.Context, body string) (database.ChirpEntities, error) {
+
+	ents := database.ChirpEntities{
+		Tags: entities.Hashtags(body),
+	}
+
+	// Look up all mentioned handles at once
+	mentions := entities.Mentions(body)
+	if len(mentions) == 0 {
+		return ents, nil
+	}
+	handles := make([]string, 0, len(mentions))
+	for _, mention := range mentions {
+		handles = append(handles, mention.Handle)
+	}
+	users, err := cfg.db.ListUsersByHandles(ctx, handles)
+	if err != nil {
+		return database.ChirpEntities{}, err
+	}
+	userIDs := make(map[string]uuid.UUID, len(users))
+	for _, user := range users {
+		userIDs[user.Handle.String] = user.ID
+	}
+
+	for _, mention := range mentions {
+		userID, ok := userIDs[mention.Handle]
+		if !ok {
+			continue
+		}
+		ents.Mentions = append(ents.Mentions, database.MentionEntity{
+			UserID:      userID,
+			StartOffset: int32(mention.Start),
+			EndOffset:   int32(mention.End),
+		})
+	}
+	return ents, nil
+}
+
 // Function to clean up chirp body for designated bodywords
 func getCleanedBody(body string, badWords map[string]struct{}) string {
 
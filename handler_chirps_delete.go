@@ -1,10 +1,10 @@
 package main
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
 
-	"chirpy/internal/auth"
-
 	"github.com/google/uuid"
 )
 
@@ -21,21 +21,12 @@ func (cfg *apiConfig) handlerChirpsDelete(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// Gather and validate JWT bearer token to generate UserID
-	token, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
-		return
-	}
-	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
-		return
-	}
+	// Gather UserID of principal authenticated by middleware
+	userID := principalFrom(r).UserID
 
 	// Retreive chirp from database via specified ID
 	dbChirp, err := cfg.db.GetChirp(r.Context(), chirpID)
-	if err != nil {
+	if err != nil || dbChirp.DeletedAt.Valid {
 		respondWithError(w, http.StatusNotFound, "Couldn't get chirp", err)
 		return
 	}
@@ -46,9 +37,13 @@ func (cfg *apiConfig) handlerChirpsDelete(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// Delete chirp from database
-	err = cfg.db.DeleteChirp(r.Context(), chirpID)
+	// Delete chirp from database (leaving a tombstone if it has replies)
+	err = cfg.db.DeleteChirpTx(r.Context(), chirpID)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't get chirp", err)
+			return
+		}
 		respondWithError(w, http.StatusInternalServerError, "Couldn't delete chirp", err)
 		return
 	}
@@ -2,7 +2,8 @@ package main
 
 import (
 	"net/http"
-	"sort"
+
+	"chirpy/internal/database"
 
 	"github.com/google/uuid"
 )
@@ -20,6 +21,9 @@ func (cfg *apiConfig) handlerChirpsGet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Gather viewing user if a bearer token was provided
+	viewerID := viewerIDFrom(r)
+
 	// Retreive chirp from database via specified ID
 	dbChirp, err := cfg.db.GetChirp(r.Context(), chirpID)
 	if err != nil {
@@ -27,23 +31,40 @@ func (cfg *apiConfig) handlerChirpsGet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Deleted chirps only remain as tombstones within threads
+	if dbChirp.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Couldn't get chirp", nil)
+		return
+	}
+
+	// Set mentions and, for authenticated viewers, liked_by_me flag
+	chirps := []Chirp{chirpFromDB(dbChirp)}
+	err = cfg.decorateChirps(r.Context(), viewerID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retreive chirp details", err)
+		return
+	}
+
 	// Call function to respond with JSON containing specified chirp data
-	respondWithJSON(w, http.StatusOK, Chirp{
-		ID:        dbChirp.ID,
-		CreatedAt: dbChirp.CreatedAt,
-		UpdatedAt: dbChirp.UpdatedAt,
-		UserID:    dbChirp.UserID,
-		Body:      dbChirp.Body,
-	})
+	respondWithJSON(w, http.StatusOK, chirps[0])
 }
 
-// Handler function to retrieve all chirps from database
+// Handler function to retrieve a page of chirps from database
 func (cfg *apiConfig) handlerChirpsRetrieve(w http.ResponseWriter, r *http.Request) {
 
-	// Retreive all chirps from database
-	dbChirps, err := cfg.db.GetChirps(r.Context())
+	// Struct for paginated JSON response
+	type response struct {
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor *string `json:"next_cursor"`
+	}
+
+	// Gather viewing user if a bearer token was provided
+	viewerID := viewerIDFrom(r)
+
+	// Gather and validate limit and cursor parameters
+	page, err := parsePageParams(r)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't retreive chirps", err)
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
 		return
 	}
 
@@ -58,41 +79,122 @@ func (cfg *apiConfig) handlerChirpsRetrieve(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
-	// Sort chirps in ascending order, unless specified as descending via optional parameter
-	sortDirection := "asc"
-	sortDirectionParam := r.URL.Query().Get("sort")
-	if sortDirectionParam == "desc" {
-		sortDirection = "desc"
+	// Retreive chirps in ascending order, unless specified as descending via optional parameter
+	var chirps []Chirp
+	var nextCursor *string
+	desc := r.URL.Query().Get("sort") == "desc"
+	if authorID != uuid.Nil {
+		chirps, nextCursor, err = cfg.listAuthorFeed(r, authorID, page, desc)
+	} else {
+		chirps, nextCursor, err = cfg.listChirps(r, page, desc)
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retreive chirps", err)
+		return
 	}
 
-	// Create an array for chirps
-	chirps := []Chirp{}
+	// Set mentions and, for authenticated viewers, liked_by_me flag
+	err = cfg.decorateChirps(r.Context(), viewerID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retreive chirp details", err)
+		return
+	}
+
+	// Call function to respond with JSON containing page of chirps
+	respondWithJSON(w, http.StatusOK, response{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
+
+// Method to retrieve a page of all chirps
+func (cfg *apiConfig) listChirps(r *http.Request, page pageParams, desc bool) ([]Chirp, *string, error) {
+
+	var dbChirps []database.Chirp
+	var err error
+	if desc {
+		dbChirps, err = cfg.db.ListChirpsDesc(r.Context(), database.ListChirpsDescParams{
+			AfterCreatedAt: page.afterCreatedAt(),
+			AfterID:        page.afterID(),
+			PageSize:       page.fetchSize(),
+		})
+	} else {
+		dbChirps, err = cfg.db.ListChirpsAsc(r.Context(), database.ListChirpsAscParams{
+			AfterCreatedAt: page.afterCreatedAt(),
+			AfterID:        page.afterID(),
+			PageSize:       page.fetchSize(),
+		})
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// If an extra row was returned there is another page - drop it and point the cursor at the last chirp
+	var nextCursor *string
+	if len(dbChirps) > int(page.Limit) {
+		dbChirps = dbChirps[:page.Limit]
+		last := dbChirps[len(dbChirps)-1]
+		cursor := encodeCursor(last.CreatedAt, last.ID)
+		nextCursor = &cursor
+	}
 
 	// Loop through each chirp and append to chirps array for JSON response
+	chirps := []Chirp{}
 	for _, dbChirp := range dbChirps {
+		chirps = append(chirps, chirpFromDB(dbChirp))
+	}
+	return chirps, nextCursor, nil
+}
 
-		// If Author ID was provided, only gather chirps from specified author
-		if authorID != uuid.Nil && dbChirp.UserID != authorID {
-			continue
+// Method to retrieve a page of an author's profile: their chirps and the chirps they rechirped
+func (cfg *apiConfig) listAuthorFeed(r *http.Request, authorID uuid.UUID, page pageParams, desc bool) ([]Chirp, *string, error) {
+
+	// Both sort directions return the same row shape
+	var rows []database.ListAuthorFeedAscRow
+	if desc {
+		descRows, err := cfg.db.ListAuthorFeedDesc(r.Context(), database.ListAuthorFeedDescParams{
+			AuthorID:       authorID,
+			AfterCreatedAt: page.afterCreatedAt(),
+			AfterID:        page.afterID(),
+			PageSize:       page.fetchSize(),
+		})
+		if err != nil {
+			return nil, nil, err
 		}
-
-		chirps = append(chirps, Chirp{
-			ID:        dbChirp.ID,
-			CreatedAt: dbChirp.CreatedAt,
-			UpdatedAt: dbChirp.UpdatedAt,
-			UserID:    dbChirp.UserID,
-			Body:      dbChirp.Body,
+		for _, row := range descRows {
+			rows = append(rows, database.ListAuthorFeedAscRow(row))
+		}
+	} else {
+		var err error
+		rows, err = cfg.db.ListAuthorFeedAsc(r.Context(), database.ListAuthorFeedAscParams{
+			AuthorID:       authorID,
+			AfterCreatedAt: page.afterCreatedAt(),
+			AfterID:        page.afterID(),
+			PageSize:       page.fetchSize(),
 		})
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
-	// Sort chirps array
-	sort.Slice(chirps, func(i, j int) bool {
-		if sortDirection == "desc" {
-			return chirps[i].CreatedAt.After(chirps[j].CreatedAt)
-		}
-		return chirps[i].CreatedAt.Before(chirps[j].CreatedAt)
-	})
+	// If an extra row was returned there is another page - cursor follows the activity time
+	var nextCursor *string
+	if len(rows) > int(page.Limit) {
+		rows = rows[:page.Limit]
+		last := rows[len(rows)-1]
+		cursor := encodeCursor(last.ActivityAt, last.Chirp.ID)
+		nextCursor = &cursor
+	}
 
-	// Call function to respond with JSON containing array for chirps
-	respondWithJSON(w, http.StatusOK, chirps)
+	// Rechirped entries carry the ID of the user who rechirped them
+	chirps := []Chirp{}
+	for _, row := range rows {
+		chirp := chirpFromDB(row.Chirp)
+		if row.RechirpedBy != uuid.Nil {
+			rechirpedBy := row.RechirpedBy
+			chirp.RechirpedBy = &rechirpedBy
+		}
+		chirps = append(chirps, chirp)
+	}
+	return chirps, nextCursor, nil
 }
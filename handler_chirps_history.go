@@ -0,0 +1,77 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Struct for a previous version of an edited chirp
+type ChirpRevision struct {
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+// Handler function to retrieve the edit history of a chirp
+func (cfg *apiConfig) handlerChirpsHistory(w http.ResponseWriter, r *http.Request) {
+
+	// Struct for JSON response
+	type response struct {
+		Chirp     Chirp           `json:"chirp"`
+		Revisions []ChirpRevision `json:"revisions"`
+	}
+
+	// Get specified chirp ID
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	// Gather viewing user if a bearer token was provided
+	viewerID := viewerIDFrom(r)
+
+	// Retreive chirp from database via specified ID
+	dbChirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && dbChirp.DeletedAt.Valid) {
+		respondWithError(w, http.StatusNotFound, "Couldn't get chirp", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirp", err)
+		return
+	}
+
+	// Retreive previous versions, oldest first
+	dbRevisions, err := cfg.db.ListChirpRevisions(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retreive chirp history", err)
+		return
+	}
+
+	revisions := []ChirpRevision{}
+	for _, dbRevision := range dbRevisions {
+		revisions = append(revisions, ChirpRevision{
+			Body:       dbRevision.Body,
+			CreatedAt:  dbRevision.CreatedAt,
+			ReplacedAt: dbRevision.ReplacedAt,
+		})
+	}
+
+	// Set mentions and, for authenticated viewers, liked_by_me flag
+	chirps := []Chirp{chirpFromDB(dbChirp)}
+	err = cfg.decorateChirps(r.Context(), viewerID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retreive chirp details", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Chirp:     chirps[0],
+		Revisions: revisions,
+	})
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Handler function for the authenticated user to rechirp a chirp
+func (cfg *apiConfig) handlerChirpsRechirp(w http.ResponseWriter, r *http.Request) {
+
+	// Get specified chirp ID
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	// Gather UserID of principal authenticated by middleware
+	userID := principalFrom(r).UserID
+
+	// Verify chirp exists and hasn't been deleted
+	dbChirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't get chirp", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirp", err)
+		return
+	}
+	if dbChirp.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Couldn't get chirp", nil)
+		return
+	}
+
+	// Add rechirp and update counter (repeating is a no-op). The chirp may have been deleted
+	// since it was checked.
+	err = cfg.db.RechirpTx(r.Context(), database.CreateRechirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't get chirp", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't rechirp chirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Handler function for the authenticated user to undo rechirp of a chirp
+func (cfg *apiConfig) handlerChirpsUndoRechirp(w http.ResponseWriter, r *http.Request) {
+
+	// Get specified chirp ID
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	// Gather UserID of principal authenticated by middleware
+	userID := principalFrom(r).UserID
+
+	// Remove rechirp and update counter
+	err = cfg.db.UndoRechirpTx(r.Context(), database.DeleteRechirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't undo rechirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"net/http"
+
+	"chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Handler function to retrieve a chirp's conversation: its ancestors and a page of its replies
+func (cfg *apiConfig) handlerChirpsThread(w http.ResponseWriter, r *http.Request) {
+
+	// Struct for a reply along with its depth below the requested chirp
+	type threadReply struct {
+		Chirp
+		Depth int32 `json:"depth"`
+	}
+
+	// Struct for paginated JSON response
+	type response struct {
+		Chirp      Chirp         `json:"chirp"`
+		Ancestors  []Chirp       `json:"ancestors"`
+		Replies    []threadReply `json:"replies"`
+		NextCursor *string       `json:"next_cursor"`
+	}
+
+	// Get specified chirp ID
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	// Gather viewing user if a bearer token was provided
+	viewerID := viewerIDFrom(r)
+
+	// Gather and validate limit and cursor parameters
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	// Cursors for tree-ordered pages must carry the path of the last reply
+	if page.Cursor != nil && page.Cursor.Path == "" {
+		respondWithError(w, http.StatusBadRequest, "Invalid cursor", nil)
+		return
+	}
+
+	// Retreive chirp from database via specified ID (tombstones are allowed so threads don't break)
+	dbChirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't get chirp", err)
+		return
+	}
+
+	// Retreive chain of parent chirps, root first
+	dbAncestors, err := cfg.db.ListChirpAncestors(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retreive ancestors", err)
+		return
+	}
+	ancestors := []Chirp{}
+	for _, dbAncestor := range dbAncestors {
+		ancestors = append(ancestors, chirpFromDB(dbAncestor))
+	}
+
+	// Retreive page of replies in depth-first tree order
+	dbReplies, err := cfg.db.ListChirpDescendants(r.Context(), database.ListChirpDescendantsParams{
+		ChirpID:      chirpID,
+		AfterSortKey: page.afterPath(),
+		PageSize:     page.fetchSize(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retreive replies", err)
+		return
+	}
+
+	// If an extra row was returned there is another page
+	var nextCursor *string
+	if len(dbReplies) > int(page.Limit) {
+		dbReplies = dbReplies[:page.Limit]
+		last := dbReplies[len(dbReplies)-1]
+		cursor := encodeTreeCursor(last.SortKey, last.Chirp.CreatedAt, last.Chirp.ID)
+		nextCursor = &cursor
+	}
+
+	replies := []threadReply{}
+	for _, dbReply := range dbReplies {
+		replies = append(replies, threadReply{
+			Chirp: chirpFromDB(dbReply.Chirp),
+			Depth: dbReply.Depth,
+		})
+	}
+
+	// Set mentions and, for authenticated viewers, liked_by_me flag on every chirp in the thread
+	chirp := []Chirp{chirpFromDB(dbChirp)}
+	replyChirps := make([]Chirp, len(replies))
+	for i := range replies {
+		replyChirps[i] = replies[i].Chirp
+	}
+	for _, chirps := range [][]Chirp{chirp, ancestors, replyChirps} {
+		err = cfg.decorateChirps(r.Context(), viewerID, chirps)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't retreive chirp details", err)
+			return
+		}
+	}
+	for i := range replies {
+		replies[i].Chirp = replyChirps[i]
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Chirp:      chirp[0],
+		Ancestors:  ancestors,
+		Replies:    replies,
+		NextCursor: nextCursor,
+	})
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Handler function for the author to edit a chirp within their plan's edit window
+func (cfg *apiConfig) handlerChirpsUpdate(w http.ResponseWriter, r *http.Request) {
+
+	// Setup struct for expected JSON parameters
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	// Get specified chirp ID
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	// Gather UserID of principal authenticated by middleware
+	userID := principalFrom(r).UserID
+
+	// Decode JSON and gather parameters
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	// Retreive chirp from database via specified ID
+	dbChirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+	if err != nil || dbChirp.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Couldn't get chirp", err)
+		return
+	}
+
+	// Verify user authorization to edit chirp
+	if dbChirp.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "You can't edit this chirp", nil)
+		return
+	}
+
+	// Gather the user's plan limits, which decide whether and for how long they can edit
+	limits, err := cfg.limitsFor(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get plan limits", err)
+		return
+	}
+	if !limits.CanEditChirps() {
+		respondWithError(w, http.StatusForbidden, "Editing chirps isn't available on your plan", nil)
+		return
+	}
+
+	// Chirps can only be edited for a limited time after they are posted
+	if time.Since(dbChirp.CreatedAt) > limits.EditWindow {
+		respondWithError(w, http.StatusForbidden, "Edit window for this chirp has passed", nil)
+		return
+	}
+
+	// Call function to validate chirp body
+	cleaned, err := validateChirp(params.Body, limits.MaxChirpLength)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	// Parse hashtags and mentions from chirp body
+	ents, err := cfg.parseChirpEntities(r.Context(), cleaned)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't resolve mentions", err)
+		return
+	}
+
+	// Save previous body as a revision and update chirp and its entities in database
+	chirp, err := cfg.db.EditChirpTx(r.Context(), chirpID, cleaned, ents)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't get chirp", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't edit chirp", err)
+		return
+	}
+
+	// Render mentions as structured entities
+	chirps := []Chirp{chirpFromDB(chirp)}
+	err = cfg.attachMentions(r.Context(), chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retreive mentions", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirps[0])
+}
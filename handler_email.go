@@ -0,0 +1,179 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"chirpy/internal/auth"
+	"chirpy/internal/database"
+)
+
+// Handler function to verify a user's email address with the token emailed to them. Works
+// as a GET from the emailed link or a POST with a JSON token.
+func (cfg *apiConfig) handlerVerifyEmail(w http.ResponseWriter, r *http.Request) {
+
+	// Struct for JSON request parameters
+	type parameters struct {
+		Token string `json:"token"`
+	}
+
+	// Struct for JSON response
+	type response struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+	}
+
+	// Gather token from link or JSON body
+	token := r.URL.Query().Get("token")
+	if r.Method == http.MethodPost {
+		decoder := json.NewDecoder(r.Body)
+		params := parameters{}
+		err := decoder.Decode(&params)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+			return
+		}
+		token = params.Token
+	}
+	if token == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing token", nil)
+		return
+	}
+
+	user, err := cfg.db.VerifyEmailTx(r.Context(), auth.HashEmailToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusBadRequest, "Invalid or expired token", err)
+		return
+	}
+	if errors.Is(err, database.ErrEmailChanged) {
+		respondWithError(w, http.StatusConflict, "Email address has changed since this link was sent", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't verify email", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Email:         user.Email,
+		EmailVerified: true,
+	})
+}
+
+// Handler function to send the authenticated user a new verification email
+func (cfg *apiConfig) handlerVerifyEmailResend(w http.ResponseWriter, r *http.Request) {
+
+	// Gather UserID of principal authenticated by middleware
+	userID := principalFrom(r).UserID
+
+	user, err := cfg.db.GetUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	if user.EmailVerifiedAt.Valid {
+		respondWithError(w, http.StatusConflict, "Email address is already verified", nil)
+		return
+	}
+
+	err = cfg.queueEmailVerification(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't send verification email", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Handler function to email a password reset token. The response is the same whether or
+// not the address belongs to an account.
+func (cfg *apiConfig) handlerPasswordResetRequest(w http.ResponseWriter, r *http.Request) {
+
+	// Struct for JSON request parameters
+	type parameters struct {
+		Email string `json:"email"`
+	}
+
+	// Decode JSON and gather parameters
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	user, err := cfg.db.GetUserByEmail(r.Context(), params.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+
+	// Issue the token from a background job, so response times match unknown addresses
+	err = cfg.queuePasswordReset(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("Error queueing password reset: %s", err)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Handler function to set a new password with an emailed reset token, logging the user
+// out everywhere
+func (cfg *apiConfig) handlerPasswordResetConfirm(w http.ResponseWriter, r *http.Request) {
+
+	// Struct for JSON request parameters
+	type parameters struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	// Decode JSON and gather parameters
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	if params.Token == "" || params.Password == "" {
+		respondWithError(w, http.StatusBadRequest, "Token and password are required", nil)
+		return
+	}
+
+	// Check new password against the password policy
+	err = cfg.passwordPolicy.Validate(params.Password)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	// Hash new password before storing in
+	hashedPassword, err := cfg.passwords.Hash(params.Password)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't hash password", err)
+		return
+	}
+
+	// Access tokens issued up to now stop working, as with logging out everywhere
+	validAfter := auth.RevocationCutoff(time.Now())
+	_, err = cfg.db.ResetPasswordTx(r.Context(), auth.HashEmailToken(params.Token), hashedPassword, validAfter)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusBadRequest, "Invalid or expired token", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reset password", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
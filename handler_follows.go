@@ -0,0 +1,210 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Handler function for the authenticated user to follow another user
+func (cfg *apiConfig) handlerFollowCreate(w http.ResponseWriter, r *http.Request) {
+
+	// Get specified user ID
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	// Gather UserID of principal authenticated by middleware
+	userID := principalFrom(r).UserID
+
+	// Users can't follow themselves
+	if followeeID == userID {
+		respondWithError(w, http.StatusBadRequest, "You can't follow yourself", nil)
+		return
+	}
+
+	// Verify user to follow exists
+	_, err = cfg.db.GetUser(r.Context(), followeeID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+
+	// Add follow to database and notify followee (following twice is a no-op)
+	err = cfg.db.FollowUserTx(r.Context(), database.CreateFollowParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't follow user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Handler function for the authenticated user to unfollow another user
+func (cfg *apiConfig) handlerFollowDelete(w http.ResponseWriter, r *http.Request) {
+
+	// Get specified user ID
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	// Gather UserID of principal authenticated by middleware
+	userID := principalFrom(r).UserID
+
+	// Remove follow from database
+	err = cfg.db.DeleteFollow(r.Context(), database.DeleteFollowParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unfollow user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Handler function to retrieve a page of users following the specified user
+func (cfg *apiConfig) handlerFollowersGet(w http.ResponseWriter, r *http.Request) {
+
+	// Struct for paginated JSON response
+	type response struct {
+		Users      []User  `json:"users"`
+		NextCursor *string `json:"next_cursor"`
+	}
+
+	// Get specified user ID
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	// Gather and validate limit and cursor parameters
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	// Retreive followers from database, newest first
+	dbUsers, err := cfg.db.ListFollowers(r.Context(), database.ListFollowersParams{
+		UserID:         userID,
+		AfterCreatedAt: page.afterCreatedAt(),
+		AfterID:        page.afterID(),
+		PageSize:       page.fetchSize(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retreive followers", err)
+		return
+	}
+
+	// If an extra row was returned there is another page
+	var nextCursor *string
+	if len(dbUsers) > int(page.Limit) {
+		dbUsers = dbUsers[:page.Limit]
+		last := dbUsers[len(dbUsers)-1]
+		cursor := encodeCursor(last.FollowedAt, last.ID)
+		nextCursor = &cursor
+	}
+
+	// Build public user list (emails are not shared with other users)
+	users := []User{}
+	for _, dbUser := range dbUsers {
+		users = append(users, User{
+			ID:             dbUser.ID,
+			CreatedAt:      dbUser.CreatedAt,
+			UpdatedAt:      dbUser.UpdatedAt,
+			Handle:         dbUser.Handle.String,
+			DisplayName:    dbUser.DisplayName.String,
+			IsChirpyRed:    dbUser.IsChirpyRed,
+			FollowerCount:  dbUser.FollowerCount,
+			FollowingCount: dbUser.FollowingCount,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Users:      users,
+		NextCursor: nextCursor,
+	})
+}
+
+// Handler function to retrieve a page of users the specified user follows
+func (cfg *apiConfig) handlerFollowingGet(w http.ResponseWriter, r *http.Request) {
+
+	// Struct for paginated JSON response
+	type response struct {
+		Users      []User  `json:"users"`
+		NextCursor *string `json:"next_cursor"`
+	}
+
+	// Get specified user ID
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	// Gather and validate limit and cursor parameters
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	// Retreive followed users from database, newest first
+	dbUsers, err := cfg.db.ListFollowing(r.Context(), database.ListFollowingParams{
+		UserID:         userID,
+		AfterCreatedAt: page.afterCreatedAt(),
+		AfterID:        page.afterID(),
+		PageSize:       page.fetchSize(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retreive followed users", err)
+		return
+	}
+
+	// If an extra row was returned there is another page
+	var nextCursor *string
+	if len(dbUsers) > int(page.Limit) {
+		dbUsers = dbUsers[:page.Limit]
+		last := dbUsers[len(dbUsers)-1]
+		cursor := encodeCursor(last.FollowedAt, last.ID)
+		nextCursor = &cursor
+	}
+
+	// Build public user list (emails are not shared with other users)
+	users := []User{}
+	for _, dbUser := range dbUsers {
+		users = append(users, User{
+			ID:             dbUser.ID,
+			CreatedAt:      dbUser.CreatedAt,
+			UpdatedAt:      dbUser.UpdatedAt,
+			Handle:         dbUser.Handle.String,
+			DisplayName:    dbUser.DisplayName.String,
+			IsChirpyRed:    dbUser.IsChirpyRed,
+			FollowerCount:  dbUser.FollowerCount,
+			FollowingCount: dbUser.FollowingCount,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Users:      users,
+		NextCursor: nextCursor,
+	})
+}
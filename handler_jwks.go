@@ -0,0 +1,11 @@
+package main
+
+import "net/http"
+
+// Handler function to publish the public keys that verify access tokens as a JSON Web Key Set
+func (cfg *apiConfig) handlerJWKS(w http.ResponseWriter, r *http.Request) {
+
+	// Verifiers may cache keys briefly; rotations keep the old key published for a while
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respondWithJSON(w, http.StatusOK, cfg.jwtKeys.JWKS())
+}
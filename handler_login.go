@@ -1,12 +1,18 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 	"time"
 
 	"chirpy/internal/auth"
 	"chirpy/internal/database"
+
+	"github.com/google/uuid"
 )
 
 // Handler function for a user login
@@ -18,11 +24,10 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 		Email    string `json:"email"`
 	}
 
-	// User struct for JSON response
-	type response struct {
-		User
-		Token        string `json:"token"`
-		RefreshToken string `json:"refresh_token"`
+	// Struct for JSON response when a second factor is still needed
+	type mfaResponse struct {
+		MFARequired bool   `json:"mfa_required"`
+		MFAToken    string `json:"mfa_token"`
 	}
 
 	// Decode JSON and gather parameters
@@ -34,24 +39,109 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Find user in database via email and password
+	// Refuse attempts while the account or IP is backing off from failed logins
+	throttleKeys := newLoginThrottleKeys(params.Email, clientIP(r))
+	if cfg.refuseThrottledLogin(w, r, throttleKeys) {
+		return
+	}
+
+	// Find user in database via email. Unknown emails still cost a password check and count
+	// as a failure, so they can't be told apart from wrong passwords.
 	user, err := cfg.db.GetUserByEmail(r.Context(), params.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		cfg.passwords.EqualizeCheck(params.Password)
+		cfg.failLogin(w, r, throttleKeys, nil, err)
+		return
+	}
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Incorrect email or password", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
 		return
 	}
 
 	// Check password against hash password
-	err = auth.CheckPasswordHash(params.Password, user.HashedPassword)
+	rehash, err := cfg.passwords.Check(params.Password, user.HashedPassword)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Incorrect email or password", err)
+		cfg.failLogin(w, r, throttleKeys, &user, err)
+		return
+	}
+
+	// Upgrade hashes using an older algorithm or cost now the password is known
+	if rehash {
+		cfg.rehashPassword(r.Context(), user, params.Password)
+	}
+
+	// Users with two-factor authentication get a challenge token to exchange at /api/login/mfa
+	if user.TotpEnabled {
+		mfaToken, err := auth.MakeMFAToken(user.ID, cfg.jwtKeys, mfaTokenExpiresIn)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't create MFA token", err)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, mfaResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		})
+		return
+	}
+
+	cfg.completeLogin(w, r, user, throttleKeys)
+}
+
+// Method to replace a user's password hash with one from the current hasher. Failures are
+// only logged, as the login itself succeeded.
+func (cfg *apiConfig) rehashPassword(ctx context.Context, user database.User, password string) {
+	hashedPassword, err := cfg.passwords.Hash(password)
+	if err != nil {
+		log.Printf("Error rehashing password: %s", err)
+		return
+	}
+
+	// Only replace the hash that was checked, in case the password changed meanwhile
+	_, err = cfg.db.RehashUserPassword(ctx, database.RehashUserPasswordParams{
+		NewHash: hashedPassword,
+		ID:      user.ID,
+		OldHash: user.HashedPassword,
+	})
+	if err != nil {
+		log.Printf("Error saving rehashed password: %s", err)
+	}
+}
+
+// Method to record a failed login and respond with the same error for wrong passwords and unknown emails
+func (cfg *apiConfig) failLogin(w http.ResponseWriter, r *http.Request, keys loginThrottleKeys, user *database.User, err error) {
+	recordErr := cfg.recordLoginFailure(r.Context(), keys, user)
+	if recordErr != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't record login attempt", recordErr)
+		return
+	}
+	respondWithError(w, http.StatusUnauthorized, "Incorrect email or password", err)
+}
+
+// Method to finish a successful login by starting a new session and responding with
+// user details, an access token and a refresh token. Called once every factor has passed.
+func (cfg *apiConfig) completeLogin(w http.ResponseWriter, r *http.Request, user database.User, keys loginThrottleKeys) {
+
+	// User struct for JSON response
+	type response struct {
+		User
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	// Suspended accounts and those an admin has flagged for a password reset can't log in
+	if user.SuspendedAt.Valid {
+		respondWithError(w, http.StatusForbidden, "Account is suspended", nil)
+		return
+	}
+	if user.PasswordResetRequired {
+		respondWithError(w, http.StatusForbidden, "Password reset required, check your email or request a new reset", nil)
 		return
 	}
 
 	// Generate JWT access token
 	accessToken, err := auth.MakeJWT(
 		user.ID,
-		cfg.jwtSecret,
+		cfg.jwtKeys,
 		time.Hour,
 	)
 	if err != nil {
@@ -66,25 +156,53 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Add refresh token to database
-	_, err = cfg.db.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
-		UserID:    user.ID,
-		Token:     refreshToken,
-		ExpiresAt: time.Now().UTC().Add(time.Hour * 24 * 60),
-	})
+	// Add refresh token to database as the start of a new session (token family)
+	refreshParams := newRefreshTokenParams(r, refreshToken)
+	refreshParams.UserID = user.ID
+	refreshParams.FamilyID = uuid.New()
+	_, err = cfg.db.CreateRefreshToken(r.Context(), refreshParams)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't save refresh token", err)
 		return
 	}
 
-	// If password is correct, respond with User details in JSON format
+	// Gather follower and following counts for user
+	counts, err := cfg.db.GetFollowCounts(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get follow counts", err)
+		return
+	}
+
+	// Gather subscription status and renewal date
+	subscription, err := cfg.subscriptionFor(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get subscription", err)
+		return
+	}
+
+	// A successful login clears the account's failures (the IP's are kept). Doing this only
+	// here keeps a known password from resetting the lockout on guesses at the second factor,
+	// or on a suspended or reset-flagged account.
+	err = cfg.db.ClearLoginThrottle(r.Context(), keys.account)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reset login attempts", err)
+		return
+	}
+
+	// Respond with User details in JSON format
 	respondWithJSON(w, http.StatusOK, response{
 		User: User{
-			ID:          user.ID,
-			CreatedAt:   user.CreatedAt,
-			UpdatedAt:   user.UpdatedAt,
-			Email:       user.Email,
-			IsChirpyRed: user.IsChirpyRed,
+			ID:             user.ID,
+			CreatedAt:      user.CreatedAt,
+			UpdatedAt:      user.UpdatedAt,
+			Email:          user.Email,
+			EmailVerified:  emailVerified(user),
+			Handle:         user.Handle.String,
+			DisplayName:    user.DisplayName.String,
+			IsChirpyRed:    user.IsChirpyRed,
+			Subscription:   subscription,
+			FollowerCount:  counts.FollowerCount,
+			FollowingCount: counts.FollowingCount,
 		},
 		Token:        accessToken,
 		RefreshToken: refreshToken,
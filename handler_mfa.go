@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"chirpy/internal/auth"
+	"chirpy/internal/database"
+)
+
+// MFA challenge tokens must be exchanged within five minutes of the password step
+const mfaTokenExpiresIn = 5 * time.Minute
+
+// Issuer shown next to the account in authenticator apps
+const totpIssuer = "Chirpy"
+
+// Method to check a second factor for a user: either a current TOTP code or an unused
+// recovery code. Each code only works once.
+func (cfg *apiConfig) verifySecondFactor(ctx context.Context, user database.User, code, recoveryCode string) error {
+	if recoveryCode != "" {
+		n, err := cfg.db.UseRecoveryCode(ctx, database.UseRecoveryCodeParams{
+			UserID:   user.ID,
+			CodeHash: auth.HashRecoveryCode(recoveryCode),
+		})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return errors.New("invalid recovery code")
+		}
+		return nil
+	}
+
+	step, err := auth.ValidateTOTP(user.TotpSecret.String, code, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// Record the time step so the same code can't be replayed
+	n, err := cfg.db.UseTOTPStep(ctx, database.UseTOTPStepParams{
+		ID:           user.ID,
+		TotpLastStep: step,
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errors.New("TOTP code already used")
+	}
+	return nil
+}
+
+// Handler function to start two-factor enrollment by generating a TOTP secret for the authenticated user
+func (cfg *apiConfig) handlerTOTPEnroll(w http.ResponseWriter, r *http.Request) {
+
+	// Struct for JSON response
+	type response struct {
+		Secret     string `json:"secret"`
+		OTPAuthURI string `json:"otpauth_uri"`
+	}
+
+	// Gather UserID of principal authenticated by middleware
+	userID := principalFrom(r).UserID
+
+	user, err := cfg.db.GetUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	if user.TotpEnabled {
+		respondWithError(w, http.StatusConflict, "Two-factor authentication is already enabled", nil)
+		return
+	}
+
+	// Store new secret as pending until it is confirmed with a code
+	secret, err := auth.MakeTOTPSecret()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create TOTP secret", err)
+		return
+	}
+	err = cfg.db.SetTOTPSecret(r.Context(), database.SetTOTPSecretParams{
+		ID:         userID,
+		TotpSecret: sql.NullString{String: secret, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save TOTP secret", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Secret:     secret,
+		OTPAuthURI: auth.TOTPURI(totpIssuer, user.Email, secret),
+	})
+}
+
+// Handler function to finish two-factor enrollment with a first code, returning recovery codes
+func (cfg *apiConfig) handlerTOTPConfirm(w http.ResponseWriter, r *http.Request) {
+
+	// Struct for JSON request parameters
+	type parameters struct {
+		Code string `json:"code"`
+	}
+
+	// Struct for JSON response
+	type response struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+
+	// Gather UserID of principal authenticated by middleware
+	userID := principalFrom(r).UserID
+
+	// Decode JSON and gather parameters
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	user, err := cfg.db.GetUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	if user.TotpEnabled {
+		respondWithError(w, http.StatusConflict, "Two-factor authentication is already enabled", nil)
+		return
+	}
+	if !user.TotpSecret.Valid {
+		respondWithError(w, http.StatusBadRequest, "Start enrollment first", nil)
+		return
+	}
+
+	// Verify the authenticator app produces matching codes
+	err = cfg.verifySecondFactor(r.Context(), user, params.Code, "")
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid code", err)
+		return
+	}
+
+	// Generate recovery codes, keeping only their hashes
+	recoveryCodes, err := auth.MakeRecoveryCodes()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create recovery codes", err)
+		return
+	}
+	hashes := make([]string, 0, len(recoveryCodes))
+	for _, recoveryCode := range recoveryCodes {
+		hashes = append(hashes, auth.HashRecoveryCode(recoveryCode))
+	}
+
+	err = cfg.db.EnableTOTPTx(r.Context(), userID, hashes)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't enable two-factor authentication", err)
+		return
+	}
+
+	// Recovery codes are only ever shown here
+	respondWithJSON(w, http.StatusOK, response{
+		RecoveryCodes: recoveryCodes,
+	})
+}
+
+// Handler function to turn off two-factor authentication, given a current code or recovery code
+func (cfg *apiConfig) handlerTOTPDisable(w http.ResponseWriter, r *http.Request) {
+
+	// Struct for JSON request parameters
+	type parameters struct {
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	// Gather UserID of principal authenticated by middleware
+	userID := principalFrom(r).UserID
+
+	// Decode JSON and gather parameters
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	user, err := cfg.db.GetUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	if !user.TotpEnabled {
+		respondWithError(w, http.StatusBadRequest, "Two-factor authentication is not enabled", nil)
+		return
+	}
+
+	err = cfg.verifySecondFactor(r.Context(), user, params.Code, params.RecoveryCode)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid code", err)
+		return
+	}
+
+	err = cfg.db.DisableTOTPTx(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't disable two-factor authentication", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Handler function for the second step of a login, exchanging an MFA challenge token and
+// a code for access and refresh tokens
+func (cfg *apiConfig) handlerLoginMFA(w http.ResponseWriter, r *http.Request) {
+
+	// Struct for JSON request parameters
+	type parameters struct {
+		MFAToken     string `json:"mfa_token"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	// Decode JSON and gather parameters
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	// Validate challenge token from the password step
+	userID, err := auth.ValidateMFAToken(params.MFAToken, cfg.jwtKeys)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate MFA token", err)
+		return
+	}
+
+	user, err := cfg.db.GetUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't get user", err)
+		return
+	}
+	if !user.TotpEnabled {
+		respondWithError(w, http.StatusUnauthorized, "Two-factor authentication is not enabled", nil)
+		return
+	}
+
+	// Wrong codes count towards the same backoff and lockout as wrong passwords
+	throttleKeys := newLoginThrottleKeys(user.Email, clientIP(r))
+	if cfg.refuseThrottledLogin(w, r, throttleKeys) {
+		return
+	}
+
+	err = cfg.verifySecondFactor(r.Context(), user, params.Code, params.RecoveryCode)
+	if err != nil {
+		recordErr := cfg.recordLoginFailure(r.Context(), throttleKeys, &user)
+		if recordErr != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't record login attempt", recordErr)
+			return
+		}
+		respondWithError(w, http.StatusUnauthorized, "Invalid code", err)
+		return
+	}
+
+	cfg.completeLogin(w, r, user, throttleKeys)
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Struct to contain notification information
+type Notification struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	Type      string     `json:"type"`
+	ActorID   *uuid.UUID `json:"actor_id"`
+	ChirpID   *uuid.UUID `json:"chirp_id"`
+	ReadAt    *time.Time `json:"read_at"`
+}
+
+// Function to convert a database notification into its JSON representation
+func notificationFromDB(dbNotification database.Notification) Notification {
+	notification := Notification{
+		ID:        dbNotification.ID,
+		CreatedAt: dbNotification.CreatedAt,
+		Type:      dbNotification.Type,
+	}
+	if dbNotification.ActorID.Valid {
+		notification.ActorID = &dbNotification.ActorID.UUID
+	}
+	if dbNotification.ChirpID.Valid {
+		notification.ChirpID = &dbNotification.ChirpID.UUID
+	}
+	if dbNotification.ReadAt.Valid {
+		notification.ReadAt = &dbNotification.ReadAt.Time
+	}
+	return notification
+}
+
+// Handler function to retrieve a page of the authenticated user's notifications, newest first
+func (cfg *apiConfig) handlerNotificationsGet(w http.ResponseWriter, r *http.Request) {
+
+	// Struct for paginated JSON response
+	type response struct {
+		Notifications []Notification `json:"notifications"`
+		NextCursor    *string        `json:"next_cursor"`
+	}
+
+	// Gather UserID of principal authenticated by middleware
+	userID := principalFrom(r).UserID
+
+	// Gather and validate limit and cursor parameters
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	// Check for optional unread filter
+	unreadOnly := false
+	unreadString := r.URL.Query().Get("unread")
+	if unreadString != "" {
+		unreadOnly, err = strconv.ParseBool(unreadString)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid unread filter", err)
+			return
+		}
+	}
+
+	// Retreive notifications from database, newest first
+	dbNotifications, err := cfg.db.ListNotifications(r.Context(), database.ListNotificationsParams{
+		UserID:         userID,
+		UnreadOnly:     unreadOnly,
+		AfterCreatedAt: page.afterCreatedAt(),
+		AfterID:        page.afterID(),
+		PageSize:       page.fetchSize(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retreive notifications", err)
+		return
+	}
+
+	// If an extra row was returned there is another page
+	var nextCursor *string
+	if len(dbNotifications) > int(page.Limit) {
+		dbNotifications = dbNotifications[:page.Limit]
+		last := dbNotifications[len(dbNotifications)-1]
+		cursor := encodeCursor(last.CreatedAt, last.ID)
+		nextCursor = &cursor
+	}
+
+	notifications := []Notification{}
+	for _, dbNotification := range dbNotifications {
+		notifications = append(notifications, notificationFromDB(dbNotification))
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Notifications: notifications,
+		NextCursor:    nextCursor,
+	})
+}
+
+// Handler function to mark some or all of the authenticated user's notifications as read
+func (cfg *apiConfig) handlerNotificationsRead(w http.ResponseWriter, r *http.Request) {
+
+	// Struct for JSON request parameters, either a list of IDs or all
+	type parameters struct {
+		IDs []uuid.UUID `json:"ids"`
+		All bool        `json:"all"`
+	}
+
+	// Struct for JSON response
+	type response struct {
+		Marked int64 `json:"marked"`
+	}
+
+	// Gather UserID of principal authenticated by middleware
+	userID := principalFrom(r).UserID
+
+	// Decode JSON and gather parameters
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	if !params.All && len(params.IDs) == 0 {
+		respondWithError(w, http.StatusBadRequest, "Provide ids or set all to true", nil)
+		return
+	}
+
+	// Mark notifications read in database (other users' notifications are ignored)
+	var marked int64
+	if params.All {
+		marked, err = cfg.db.MarkAllNotificationsRead(r.Context(), userID)
+	} else {
+		marked, err = cfg.db.MarkNotificationsRead(r.Context(), database.MarkNotificationsReadParams{
+			UserID: userID,
+			Ids:    params.IDs,
+		})
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't mark notifications read", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Marked: marked,
+	})
+}
+
+// Handler function to retrieve the number of unread notifications for the authenticated user
+func (cfg *apiConfig) handlerNotificationsUnreadCount(w http.ResponseWriter, r *http.Request) {
+
+	// Struct for JSON response
+	type response struct {
+		Count int64 `json:"count"`
+	}
+
+	// Gather UserID of principal authenticated by middleware
+	userID := principalFrom(r).UserID
+
+	count, err := cfg.db.CountUnreadNotifications(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't count notifications", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Count: count,
+	})
+}
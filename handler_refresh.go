@@ -1,18 +1,22 @@
 package main
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
 	"time"
 
 	"chirpy/internal/auth"
+	"chirpy/internal/database"
 )
 
-// Handler function to refresh access token
+// Handler function to refresh access token, rotating the refresh token
 func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 
-	// Struct for JSON request parameters
+	// Struct for JSON response
 	type response struct {
-		Token string `json:"token"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
 	}
 
 	// Get refresh token via bearer token
@@ -22,17 +26,32 @@ func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get user data via refresh token
-	user, err := cfg.db.GetUserFromRefreshToken(r.Context(), refreshToken)
+	// Create replacement refresh token
+	newRefreshToken, err := auth.MakeRefreshToken()
 	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create refresh token", err)
+		return
+	}
+
+	// Revoke refresh token and replace it within its family to get user data
+	user, err := cfg.db.RotateRefreshTokenTx(r.Context(), refreshToken, newRefreshTokenParams(r, newRefreshToken))
+	if errors.Is(err, database.ErrRefreshTokenReused) {
+		respondWithError(w, http.StatusUnauthorized, "Refresh token was already used, please log in again", err)
+		return
+	}
+	if errors.Is(err, sql.ErrNoRows) {
 		respondWithError(w, http.StatusUnauthorized, "Couldn't get user for refresh token", err)
 		return
 	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't rotate refresh token", err)
+		return
+	}
 
 	// Make JWT access token
 	accessToken, err := auth.MakeJWT(
 		user.ID,
-		cfg.jwtSecret,
+		cfg.jwtKeys,
 		time.Hour,
 	)
 	if err != nil {
@@ -40,9 +59,10 @@ func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Respond with access token in JSON format
+	// Respond with access token and new refresh token in JSON format
 	respondWithJSON(w, http.StatusOK, response{
-		Token: accessToken,
+		Token:        accessToken,
+		RefreshToken: newRefreshToken,
 	})
 }
 
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"chirpy/internal/database"
+	"chirpy/internal/search"
+
+	"github.com/google/uuid"
+)
+
+// Handler function to search chirps by full text, or users by handle or display name prefix
+func (cfg *apiConfig) handlerSearch(w http.ResponseWriter, r *http.Request) {
+
+	// Structs for paginated JSON responses
+	type chirpsResponse struct {
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor *string `json:"next_cursor"`
+	}
+	type usersResponse struct {
+		Users      []User  `json:"users"`
+		NextCursor *string `json:"next_cursor"`
+	}
+
+	// Gather viewing user if a bearer token was provided
+	viewerID := viewerIDFrom(r)
+
+	// Gather and validate limit and cursor parameters
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	q := r.URL.Query().Get("q")
+	switch r.URL.Query().Get("type") {
+	case "", "chirps":
+		// Parse search terms and operators
+		query, err := search.Parse(q)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+
+		chirps, nextCursor, err := cfg.searchChirps(r, query, page)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't search chirps", err)
+			return
+		}
+
+		// Set mentions and, for authenticated viewers, liked_by_me flag
+		err = cfg.decorateChirps(r.Context(), viewerID, chirps)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't retreive chirp details", err)
+			return
+		}
+
+		respondWithJSON(w, http.StatusOK, chirpsResponse{
+			Chirps:     chirps,
+			NextCursor: nextCursor,
+		})
+
+	case "users":
+		if strings.TrimPrefix(strings.TrimSpace(q), "@") == "" {
+			respondWithError(w, http.StatusBadRequest, "Search needs at least one character", nil)
+			return
+		}
+
+		users, nextCursor, err := cfg.searchUsers(r, q, page)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't search users", err)
+			return
+		}
+
+		respondWithJSON(w, http.StatusOK, usersResponse{
+			Users:      users,
+			NextCursor: nextCursor,
+		})
+
+	default:
+		respondWithError(w, http.StatusBadRequest, "Invalid search type", nil)
+	}
+}
+
+// Method to retrieve a page of chirps matching a search, most relevant first
+func (cfg *apiConfig) searchChirps(r *http.Request, query search.Query, page pageParams) ([]Chirp, *string, error) {
+
+	params := database.SearchChirpsParams{
+		Query:          query.Text,
+		AfterRank:      page.afterRank(),
+		AfterCreatedAt: page.afterCreatedAt(),
+		AfterID:        page.afterID(),
+		PageSize:       page.fetchSize(),
+	}
+	if !query.Since.IsZero() {
+		params.Since = sql.NullTime{Time: query.Since, Valid: true}
+	}
+	if !query.Until.IsZero() {
+		params.Until = sql.NullTime{Time: query.Until, Valid: true}
+	}
+
+	// Resolve from: handle to an author; an unknown handle matches nothing
+	if query.From != "" {
+		authors, err := cfg.db.ListUsersByHandles(r.Context(), []string{query.From})
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(authors) == 0 {
+			return []Chirp{}, nil, nil
+		}
+		params.AuthorID = uuid.NullUUID{UUID: authors[0].ID, Valid: true}
+	}
+
+	rows, err := cfg.db.SearchChirps(r.Context(), params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// If an extra row was returned there is another page - cursor carries the rank as well
+	var nextCursor *string
+	if len(rows) > int(page.Limit) {
+		rows = rows[:page.Limit]
+		last := rows[len(rows)-1]
+		cursor := encodeRankedCursor(last.Rank, last.Chirp.CreatedAt, last.Chirp.ID)
+		nextCursor = &cursor
+	}
+
+	chirps := []Chirp{}
+	for _, row := range rows {
+		chirps = append(chirps, chirpFromDB(row.Chirp))
+	}
+	return chirps, nextCursor, nil
+}
+
+// Method to retrieve a page of users whose handle or display name starts with a prefix, newest first
+func (cfg *apiConfig) searchUsers(r *http.Request, q string, page pageParams) ([]User, *string, error) {
+
+	dbUsers, err := cfg.db.SearchUsers(r.Context(), database.SearchUsersParams{
+		Pattern:        search.PrefixPattern(q),
+		AfterCreatedAt: page.afterCreatedAt(),
+		AfterID:        page.afterID(),
+		PageSize:       page.fetchSize(),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// If an extra row was returned there is another page
+	var nextCursor *string
+	if len(dbUsers) > int(page.Limit) {
+		dbUsers = dbUsers[:page.Limit]
+		last := dbUsers[len(dbUsers)-1]
+		cursor := encodeCursor(last.CreatedAt, last.ID)
+		nextCursor = &cursor
+	}
+
+	// Build public user list (emails are not shared with other users)
+	users := []User{}
+	for _, dbUser := range dbUsers {
+		users = append(users, User{
+			ID:             dbUser.ID,
+			CreatedAt:      dbUser.CreatedAt,
+			UpdatedAt:      dbUser.UpdatedAt,
+			Handle:         dbUser.Handle.String,
+			DisplayName:    dbUser.DisplayName.String,
+			IsChirpyRed:    dbUser.IsChirpyRed,
+			FollowerCount:  dbUser.FollowerCount,
+			FollowingCount: dbUser.FollowingCount,
+		})
+	}
+	return users, nextCursor, nil
+}
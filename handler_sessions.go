@@ -0,0 +1,125 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"chirpy/internal/auth"
+	"chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Struct to contain session information. A session is a token family: the refresh
+// tokens issued by one login and every refresh after it.
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+}
+
+// Function to get the IP address a request came from
+func clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
+
+// Function to build parameters for a new refresh token, recording the requesting device
+func newRefreshTokenParams(r *http.Request, token string) database.CreateRefreshTokenParams {
+	return database.CreateRefreshTokenParams{
+		Token:     token,
+		ExpiresAt: time.Now().UTC().Add(auth.RefreshTokenExpiresIn),
+		UserAgent: r.UserAgent(),
+		IpAddress: clientIP(r),
+	}
+}
+
+// Handler function to list the authenticated user's active sessions, most recently used first
+func (cfg *apiConfig) handlerSessionsGet(w http.ResponseWriter, r *http.Request) {
+
+	// Struct for JSON response
+	type response struct {
+		Sessions []Session `json:"sessions"`
+	}
+
+	// Gather UserID of principal authenticated by middleware
+	userID := principalFrom(r).UserID
+
+	// Retreive the live refresh token of each session
+	dbSessions, err := cfg.db.ListSessions(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retreive sessions", err)
+		return
+	}
+
+	sessions := []Session{}
+	for _, dbSession := range dbSessions {
+		sessions = append(sessions, Session{
+			ID:         dbSession.FamilyID,
+			CreatedAt:  dbSession.CreatedAt,
+			LastUsedAt: dbSession.LastUsedAt,
+			ExpiresAt:  dbSession.ExpiresAt,
+			UserAgent:  dbSession.UserAgent,
+			IPAddress:  dbSession.IpAddress,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Sessions: sessions,
+	})
+}
+
+// Handler function to revoke one of the authenticated user's sessions
+func (cfg *apiConfig) handlerSessionsDelete(w http.ResponseWriter, r *http.Request) {
+
+	// Get specified session ID
+	sessionID, err := uuid.Parse(r.PathValue("sessionID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid session ID", err)
+		return
+	}
+
+	// Gather UserID of principal authenticated by middleware
+	userID := principalFrom(r).UserID
+
+	// Revoke session's refresh tokens (only the owner's sessions match)
+	n, err := cfg.db.RevokeSession(r.Context(), database.RevokeSessionParams{
+		FamilyID: sessionID,
+		UserID:   userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't revoke session", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't find session", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Handler function to log the authenticated user out everywhere, revoking every session
+// and every access token issued so far
+func (cfg *apiConfig) handlerSessionsRevokeAll(w http.ResponseWriter, r *http.Request) {
+
+	// Gather UserID of principal authenticated by middleware
+	userID := principalFrom(r).UserID
+
+	// Access tokens issued up to now stop working
+	validAfter := auth.RevocationCutoff(time.Now())
+	err := cfg.db.RevokeAllSessionsTx(r.Context(), userID, validAfter)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't revoke sessions", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"chirpy/internal/database"
+	"chirpy/internal/stream"
+
+	"github.com/google/uuid"
+)
+
+// Handler function to push newly created and deleted chirps to the client over Server-Sent Events
+func (cfg *apiConfig) handlerStream(w http.ResponseWriter, r *http.Request) {
+
+	// Gather viewing user if a bearer token was provided
+	viewerID := viewerIDFrom(r)
+
+	// Build filter from optional author_id or following parameters
+	var filter stream.Filter
+	authorIDString := r.URL.Query().Get("author_id")
+	following := r.URL.Query().Get("following") == "true"
+	switch {
+	case authorIDString != "" && following:
+		respondWithError(w, http.StatusBadRequest, "Use either author_id or following, not both", nil)
+		return
+
+	case authorIDString != "":
+		authorID, err := uuid.Parse(authorIDString)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid author ID", err)
+			return
+		}
+		filter = func(e stream.Event) bool {
+			return e.UserID == authorID
+		}
+
+	case following:
+		if !viewerID.Valid {
+			respondWithAuthError(w, http.StatusUnauthorized, "", "", "Couldn't find token", nil)
+			return
+		}
+		// Follow list is fixed for the life of the connection
+		followeeIDs, err := cfg.db.ListFollowingIDs(r.Context(), viewerID.UUID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't retreive follow list", err)
+			return
+		}
+		followees := make(map[uuid.UUID]struct{}, len(followeeIDs))
+		for _, followeeID := range followeeIDs {
+			followees[followeeID] = struct{}{}
+		}
+		filter = func(e stream.Event) bool {
+			_, ok := followees[e.UserID]
+			return ok
+		}
+	}
+
+	// Gather Last-Event-ID sent by reconnecting clients
+	var lastEventID int64
+	var err error
+	if lastEventIDString := r.Header.Get("Last-Event-ID"); lastEventIDString != "" {
+		lastEventID, err = strconv.ParseInt(lastEventIDString, 10, 64)
+		if err != nil || lastEventID < 0 {
+			respondWithError(w, http.StatusBadRequest, "Invalid Last-Event-ID", err)
+			return
+		}
+	}
+
+	// Subscribe before replaying so no event falls between the two
+	sub := cfg.stream.Subscribe(filter, streamBufferSize)
+	defer cfg.stream.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+	err = rc.Flush()
+	if err != nil {
+		return
+	}
+
+	// Replay every event the client missed a page at a time, until a short page shows the
+	// log is exhausted. Replay starts a reorder window behind Last-Event-ID to cover events
+	// that committed out of order, so clients should ignore IDs they've already seen.
+	replayed := newPublishedEvents()
+	if lastEventID > 0 {
+		afterID := max(lastEventID-streamReorderWindow, 0)
+		for {
+			dbEvents, err := cfg.db.ListChirpEventsAfter(r.Context(), database.ListChirpEventsAfterParams{
+				AfterID:  afterID,
+				PageSize: streamReplayLimit,
+			})
+			if err != nil {
+				return
+			}
+			events, err := cfg.streamEventsFromDB(r.Context(), dbEvents)
+			if err != nil {
+				return
+			}
+			for _, event := range events {
+				if filter != nil && !filter(event) {
+					continue
+				}
+				err = writeStreamEvent(w, rc, event)
+				if err != nil {
+					return
+				}
+			}
+			for _, dbEvent := range dbEvents {
+				replayed.add(dbEvent.ID)
+			}
+			if len(dbEvents) < streamReplayLimit {
+				break
+			}
+			afterID = dbEvents[len(dbEvents)-1].ID
+		}
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-heartbeat.C:
+			// Comment lines keep proxies from closing an idle connection
+			_, err = fmt.Fprint(w, ": ping\n\n")
+			if err == nil {
+				err = rc.Flush()
+			}
+			if err != nil {
+				return
+			}
+
+		case event, ok := <-sub.Events():
+			// Closed when the client falls too far behind; it can reconnect and resume
+			if !ok {
+				return
+			}
+			// Skip events already sent during replay
+			if replayed.seen(event.ID) {
+				continue
+			}
+			err = writeStreamEvent(w, rc, event)
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Function to write a single event in Server-Sent Events format and flush it to the client
+func writeStreamEvent(w http.ResponseWriter, rc *http.ResponseController, event stream.Event) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+	if err != nil {
+		return err
+	}
+	return rc.Flush()
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"chirpy/internal/database"
+	"chirpy/internal/entities"
+)
+
+// Handler function to retrieve a page of chirps using the specified hashtag, newest first
+func (cfg *apiConfig) handlerTagChirps(w http.ResponseWriter, r *http.Request) {
+
+	// Struct for paginated JSON response
+	type response struct {
+		Tag        string  `json:"tag"`
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor *string `json:"next_cursor"`
+	}
+
+	// Get specified tag in its stored form
+	tag := entities.NormalizeTag(r.PathValue("tag"))
+	if tag == "" {
+		respondWithError(w, http.StatusBadRequest, "Invalid tag", nil)
+		return
+	}
+
+	// Gather viewing user if a bearer token was provided
+	viewerID := viewerIDFrom(r)
+
+	// Gather and validate limit and cursor parameters
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	// Retreive tagged chirps from database
+	dbChirps, err := cfg.db.ListTagChirps(r.Context(), database.ListTagChirpsParams{
+		Tag:            tag,
+		AfterCreatedAt: page.afterCreatedAt(),
+		AfterID:        page.afterID(),
+		PageSize:       page.fetchSize(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retreive chirps", err)
+		return
+	}
+
+	// If an extra row was returned there is another page
+	var nextCursor *string
+	if len(dbChirps) > int(page.Limit) {
+		dbChirps = dbChirps[:page.Limit]
+		last := dbChirps[len(dbChirps)-1]
+		cursor := encodeCursor(last.CreatedAt, last.ID)
+		nextCursor = &cursor
+	}
+
+	chirps := []Chirp{}
+	for _, dbChirp := range dbChirps {
+		chirps = append(chirps, chirpFromDB(dbChirp))
+	}
+
+	// Set mentions and, for authenticated viewers, liked_by_me flag
+	err = cfg.decorateChirps(r.Context(), viewerID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retreive chirp details", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Tag:        tag,
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
+
+// Handler function to retrieve the currently trending hashtags
+func (cfg *apiConfig) handlerTagsTrending(w http.ResponseWriter, r *http.Request) {
+
+	// Struct for a trending tag and its decayed score
+	type trendingTag struct {
+		Tag   string  `json:"tag"`
+		Score float64 `json:"score"`
+	}
+
+	// Struct for JSON response
+	type response struct {
+		Tags       []trendingTag `json:"tags"`
+		ComputedAt *time.Time    `json:"computed_at"`
+	}
+
+	// Validate optional limit, defaulting to 10 tags
+	limit := 10
+	limitString := r.URL.Query().Get("limit")
+	if limitString != "" {
+		var err error
+		limit, err = strconv.Atoi(limitString)
+		if err != nil || limit < 1 {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+			return
+		}
+		limit = min(limit, trendingMaxTags)
+	}
+
+	// Retreive tags from the table maintained by the trending worker
+	dbTags, err := cfg.db.ListTrendingTags(r.Context(), int32(limit))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retreive trending tags", err)
+		return
+	}
+
+	tags := []trendingTag{}
+	var computedAt *time.Time
+	for _, dbTag := range dbTags {
+		tags = append(tags, trendingTag{
+			Tag:   dbTag.Name,
+			Score: dbTag.Score,
+		})
+		computedAt = &dbTag.ComputedAt
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Tags:       tags,
+		ComputedAt: computedAt,
+	})
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"chirpy/internal/auth"
+	"chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Personal access tokens can be given a lifetime of up to a year, or none at all
+const maxAPITokenExpiresInDays = 365
+
+// Struct to contain personal access token information. The token itself is only
+// returned once, when it is created.
+type APIToken struct {
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Name       string     `json:"name"`
+	TokenHint  string     `json:"token_hint"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+// Function to convert a database personal access token to its JSON form
+func apiTokenFromDB(dbToken database.ApiToken) APIToken {
+	apiToken := APIToken{
+		ID:        dbToken.ID,
+		CreatedAt: dbToken.CreatedAt,
+		Name:      dbToken.Name,
+		TokenHint: dbToken.TokenHint,
+		Scopes:    dbToken.Scopes,
+	}
+	if dbToken.LastUsedAt.Valid {
+		apiToken.LastUsedAt = &dbToken.LastUsedAt.Time
+	}
+	if dbToken.ExpiresAt.Valid {
+		apiToken.ExpiresAt = &dbToken.ExpiresAt.Time
+	}
+	return apiToken
+}
+
+// Handler function to create a scoped personal access token for bots and integrations
+func (cfg *apiConfig) handlerTokensCreate(w http.ResponseWriter, r *http.Request) {
+
+	// Struct for JSON request parameters
+	type parameters struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days"`
+	}
+
+	// Struct for JSON response
+	type response struct {
+		APIToken
+		Token string `json:"token"`
+	}
+
+	// Gather UserID of principal authenticated by middleware
+	userID := principalFrom(r).UserID
+
+	// Decode JSON and gather parameters
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	// Validate name, scopes and lifetime
+	name := strings.TrimSpace(params.Name)
+	if name == "" || len(name) > 100 {
+		respondWithError(w, http.StatusBadRequest, "Name must be between 1 and 100 characters", nil)
+		return
+	}
+	scopes, err := auth.ParseScopes(params.Scopes)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if params.ExpiresInDays < 0 || params.ExpiresInDays > maxAPITokenExpiresInDays {
+		respondWithError(w, http.StatusBadRequest, "expires_in_days must be between 0 and 365", nil)
+		return
+	}
+	expiresAt := sql.NullTime{}
+	if params.ExpiresInDays > 0 {
+		expiresAt = sql.NullTime{
+			Time:  time.Now().UTC().AddDate(0, 0, params.ExpiresInDays),
+			Valid: true,
+		}
+	}
+
+	// Generate token, keeping only its hash
+	personalToken, err := auth.MakePersonalToken()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create token", err)
+		return
+	}
+	dbToken, err := cfg.db.CreateAPIToken(r.Context(), database.CreateAPITokenParams{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: auth.HashPersonalToken(personalToken),
+		TokenHint: auth.PersonalTokenHint(personalToken),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save token", err)
+		return
+	}
+
+	// The token is only ever shown here
+	respondWithJSON(w, http.StatusCreated, response{
+		APIToken: apiTokenFromDB(dbToken),
+		Token:    personalToken,
+	})
+}
+
+// Handler function to list the authenticated user's active personal access tokens
+func (cfg *apiConfig) handlerTokensGet(w http.ResponseWriter, r *http.Request) {
+
+	// Struct for JSON response
+	type response struct {
+		Tokens []APIToken `json:"tokens"`
+	}
+
+	// Gather UserID of principal authenticated by middleware
+	userID := principalFrom(r).UserID
+
+	// Retreive user's tokens
+	dbTokens, err := cfg.db.ListAPITokens(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retreive tokens", err)
+		return
+	}
+
+	tokens := []APIToken{}
+	for _, dbToken := range dbTokens {
+		tokens = append(tokens, apiTokenFromDB(dbToken))
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Tokens: tokens,
+	})
+}
+
+// Handler function to revoke one of the authenticated user's personal access tokens
+func (cfg *apiConfig) handlerTokensDelete(w http.ResponseWriter, r *http.Request) {
+
+	// Get specified token ID
+	tokenID, err := uuid.Parse(r.PathValue("tokenID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid token ID", err)
+		return
+	}
+
+	// Gather UserID of principal authenticated by middleware
+	userID := principalFrom(r).UserID
+
+	// Revoke token (only the owner's tokens match)
+	n, err := cfg.db.RevokeAPIToken(r.Context(), database.RevokeAPITokenParams{
+		ID:     tokenID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't revoke token", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't find token", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,24 +1,40 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"strings"
 	"time"
+	"unicode/utf8"
 
-	"chirpy/internal/auth"
 	"chirpy/internal/database"
+	"chirpy/internal/entities"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// Maximum length of a display name in characters
+const maxDisplayNameLength = 50
+
 // Struct to contain user information
 type User struct {
-	ID          uuid.UUID `json:"id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Email       string    `json:"email"`
-	Password    string    `json:"-"`
-	IsChirpyRed bool      `json:"is_chirpy_red"`
+	ID             uuid.UUID     `json:"id"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+	Email          string        `json:"email,omitempty"`
+	EmailVerified  *bool         `json:"email_verified,omitempty"`
+	Handle         string        `json:"handle,omitempty"`
+	DisplayName    string        `json:"display_name,omitempty"`
+	Password       string        `json:"-"`
+	IsChirpyRed    bool          `json:"is_chirpy_red"`
+	Subscription   *Subscription `json:"subscription,omitempty"`
+	FollowerCount  int64         `json:"follower_count"`
+	FollowingCount int64         `json:"following_count"`
 }
 
 // Handler function for creating a user in database
@@ -26,8 +42,10 @@ func (cfg *apiConfig) handlerUsersCreate(w http.ResponseWriter, r *http.Request)
 
 	// Struct to store JSON user email and password data
 	type parameters struct {
-		Password string `json:"password"`
-		Email    string `json:"email"`
+		Password    string `json:"password"`
+		Email       string `json:"email"`
+		Handle      string `json:"handle"`
+		DisplayName string `json:"display_name"`
 	}
 
 	// Struct to store response values for user
@@ -44,8 +62,36 @@ func (cfg *apiConfig) handlerUsersCreate(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Validate email address
+	email, err := parseEmail(params.Email)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	// Check password against the password policy
+	err = cfg.passwordPolicy.Validate(params.Password)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	// Validate optional handle used for @mentions
+	handle, err := parseHandle(params.Handle)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	// Validate optional display name
+	displayName, err := parseDisplayName(params.DisplayName)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
 	// Hash users password before storing in
-	hashedPassword, err := auth.HashPassword(params.Password)
+	hashedPassword, err := cfg.passwords.Hash(params.Password)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't hash password", err)
 		return
@@ -53,22 +99,73 @@ func (cfg *apiConfig) handlerUsersCreate(w http.ResponseWriter, r *http.Request)
 
 	// Create and add user into database
 	user, err := cfg.db.CreateUser(r.Context(), database.CreateUserParams{
-		Email:          params.Email,
+		Email:          email,
 		HashedPassword: hashedPassword,
+		Handle:         handle,
+		DisplayName:    displayName,
 	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "Email or handle already in use", err)
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't create user", err)
 		return
 	}
 
+	// Email a verification link. The account is usable either way, so a failure is only logged.
+	err = cfg.queueEmailVerification(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("Error queueing verification email: %s", err)
+	}
+
 	// Send JSON response with response struct containing user information
 	respondWithJSON(w, http.StatusCreated, response{
 		User: User{
-			ID:          user.ID,
-			CreatedAt:   user.CreatedAt,
-			UpdatedAt:   user.UpdatedAt,
-			Email:       user.Email,
-			IsChirpyRed: user.IsChirpyRed,
+			ID:            user.ID,
+			CreatedAt:     user.CreatedAt,
+			UpdatedAt:     user.UpdatedAt,
+			Email:         user.Email,
+			EmailVerified: emailVerified(user),
+			Handle:        user.Handle.String,
+			DisplayName:   user.DisplayName.String,
+			IsChirpyRed:   user.IsChirpyRed,
 		},
 	})
 }
+
+// Function to get whether a user's email is verified, for responses to the user themself
+func emailVerified(user database.User) *bool {
+	verified := user.EmailVerifiedAt.Valid
+	return &verified
+}
+
+// Function to validate and normalize an optional handle. An empty handle is stored as NULL.
+func parseHandle(handle string) (sql.NullString, error) {
+	if handle == "" {
+		return sql.NullString{}, nil
+	}
+	handle = entities.NormalizeHandle(handle)
+	if !entities.ValidHandle(handle) {
+		return sql.NullString{}, errors.New("Handle must be 3-15 letters, digits or underscores")
+	}
+	return sql.NullString{String: handle, Valid: true}, nil
+}
+
+// Function to validate an optional display name. An empty display name is stored as NULL.
+func parseDisplayName(displayName string) (sql.NullString, error) {
+	displayName = strings.TrimSpace(displayName)
+	if displayName == "" {
+		return sql.NullString{}, nil
+	}
+	if utf8.RuneCountInString(displayName) > maxDisplayNameLength {
+		return sql.NullString{}, fmt.Errorf("Display name must be at most %d characters", maxDisplayNameLength)
+	}
+	return sql.NullString{String: displayName, Valid: true}, nil
+}
+
+// Function to check whether a database error is a unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -2,10 +2,13 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 
-	"chirpy/internal/auth"
 	"chirpy/internal/database"
+
+	"github.com/lib/pq"
 )
 
 // Handler function to update a specific users email or password
@@ -13,8 +16,10 @@ func (cfg *apiConfig) handlerUsersUpdate(w http.ResponseWriter, r *http.Request)
 
 	// Struct to store JSON user email and password data
 	type parameters struct {
-		Password string `json:"password"`
-		Email    string `json:"email"`
+		Password    string `json:"password"`
+		Email       string `json:"email"`
+		Handle      string `json:"handle"`
+		DisplayName string `json:"display_name"`
 	}
 
 	// Struct to store response values for user
@@ -22,53 +27,138 @@ func (cfg *apiConfig) handlerUsersUpdate(w http.ResponseWriter, r *http.Request)
 		User
 	}
 
-	// Gather and validate JWT bearer token to generate UserID
-	token, err := auth.GetBearerToken(r.Header)
+	// Gather principal authenticated by middleware
+	principal := principalFrom(r)
+	userID := principal.UserID
+
+	// Decode JSON and gather parameters
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	// Personal access tokens may change the public profile but never the account credentials
+	personalToken := principal.TokenType == TokenTypePersonal
+	if personalToken && (params.Email != "" || params.Password != "") {
+		respondWithError(w, http.StatusForbidden, "Personal access tokens can't change email or password", nil)
 		return
 	}
-	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+
+	// Validate email address
+	email := ""
+	if !personalToken {
+		email, err = parseEmail(params.Email)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+
+		// Check new password against the password policy
+		err = cfg.passwordPolicy.Validate(params.Password)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+	}
+
+	// Retreive current user to tell whether the email changes
+	current, err := cfg.db.GetUser(r.Context(), userID)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
 		return
 	}
 
-	// Decode JSON and gather parameters
-	decoder := json.NewDecoder(r.Body)
-	params := parameters{}
-	err = decoder.Decode(&params)
+	// Validate optional handle used for @mentions
+	handle, err := parseHandle(params.Handle)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
 		return
 	}
 
-	// Hash users password before storing in
-	hashedPassword, err := auth.HashPassword(params.Password)
+	// Validate optional display name
+	displayName, err := parseDisplayName(params.DisplayName)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't hash password", err)
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
 		return
 	}
 
-	// Update user information into database
-	user, err := cfg.db.UpdateUser(r.Context(), database.UpdateUserParams{
-		ID:             userID,
-		Email:          params.Email,
-		HashedPassword: hashedPassword,
+	// Hash users password before storing in
+	hashedPassword := ""
+	if !personalToken {
+		hashedPassword, err = cfg.passwords.Hash(params.Password)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't hash password", err)
+			return
+		}
+	}
+
+	// Update user information into database in one transaction, so a taken handle or email
+	// leaves the user unchanged
+	user, err := cfg.db.UpdateUserTx(r.Context(), database.UpdateUserTxParams{
+		ID:                userID,
+		Handle:            handle,
+		DisplayName:       displayName,
+		UpdateCredentials: !personalToken,
+		Email:             email,
+		HashedPassword:    hashedPassword,
 	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, userConflictMessage(err), err)
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
 		return
 	}
 
+	// A changed address is unverified until the link sent to it is opened
+	if user.Email != current.Email {
+		err = cfg.queueEmailVerification(r.Context(), user.ID)
+		if err != nil {
+			log.Printf("Error queueing verification email: %s", err)
+		}
+	}
+
+	// Gather follower and following counts for user
+	counts, err := cfg.db.GetFollowCounts(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get follow counts", err)
+		return
+	}
+
+	// Gather subscription status and renewal date
+	subscription, err := cfg.subscriptionFor(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get subscription", err)
+		return
+	}
+
 	// Send JSON response with response struct containing user information
 	respondWithJSON(w, http.StatusOK, response{
 		User: User{
-			ID:          user.ID,
-			CreatedAt:   user.CreatedAt,
-			UpdatedAt:   user.UpdatedAt,
-			Email:       user.Email,
-			IsChirpyRed: user.IsChirpyRed,
+			ID:             user.ID,
+			CreatedAt:      user.CreatedAt,
+			UpdatedAt:      user.UpdatedAt,
+			Email:          user.Email,
+			EmailVerified:  emailVerified(user),
+			Handle:         user.Handle.String,
+			DisplayName:    user.DisplayName.String,
+			IsChirpyRed:    user.IsChirpyRed,
+			Subscription:   subscription,
+			FollowerCount:  counts.FollowerCount,
+			FollowingCount: counts.FollowingCount,
 		},
 	})
 }
+
+// Function to describe which of a user's unique fields a unique violation was for
+func userConflictMessage(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Constraint == "users_handle_key" {
+		return "Handle already in use"
+	}
+	return "Email already in use"
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"chirpy/internal/database"
+	"chirpy/internal/webhook"
+
+	"github.com/google/uuid"
+)
+
+// Users can register up to this many webhook endpoints
+const maxWebhookEndpoints = 10
+
+// Struct to contain webhook endpoint information. The signing secret is only returned
+// once, when the endpoint is created.
+type WebhookEndpoint struct {
+	ID                  uuid.UUID  `json:"id"`
+	CreatedAt           time.Time  `json:"created_at"`
+	URL                 string     `json:"url"`
+	EventTypes          []string   `json:"event_types"`
+	ConsecutiveFailures int32      `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at"`
+	DisabledReason      *string    `json:"disabled_reason"`
+}
+
+// Function to convert a database webhook endpoint to its JSON form
+func webhookEndpointFromDB(dbEndpoint database.WebhookEndpoint) WebhookEndpoint {
+	endpoint := WebhookEndpoint{
+		ID:                  dbEndpoint.ID,
+		CreatedAt:           dbEndpoint.CreatedAt,
+		URL:                 dbEndpoint.Url,
+		EventTypes:          dbEndpoint.EventTypes,
+		ConsecutiveFailures: dbEndpoint.ConsecutiveFailures,
+	}
+	if dbEndpoint.DisabledAt.Valid {
+		endpoint.DisabledAt = &dbEndpoint.DisabledAt.Time
+	}
+	if dbEndpoint.DisabledReason.Valid {
+		endpoint.DisabledReason = &dbEndpoint.DisabledReason.String
+	}
+	return endpoint
+}
+
+// Struct to contain one delivery of an event to a webhook endpoint
+type WebhookDelivery struct {
+	ID             uuid.UUID       `json:"id"`
+	CreatedAt      time.Time       `json:"created_at"`
+	EventID        uuid.UUID       `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int32           `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at"`
+	ResponseStatus *int32          `json:"response_status"`
+	ResponseBody   *string         `json:"response_body"`
+	Error          *string         `json:"error"`
+}
+
+// Function to convert a database webhook delivery to its JSON form
+func webhookDeliveryFromDB(dbDelivery database.WebhookDelivery) WebhookDelivery {
+	delivery := WebhookDelivery{
+		ID:        dbDelivery.ID,
+		CreatedAt: dbDelivery.CreatedAt,
+		EventID:   dbDelivery.EventID,
+		EventType: dbDelivery.EventType,
+		Payload:   dbDelivery.Payload,
+		Status:    dbDelivery.Status,
+		Attempts:  dbDelivery.Attempts,
+	}
+	if dbDelivery.Status == database.WebhookDeliveryStatusPending {
+		delivery.NextAttemptAt = &dbDelivery.NextAttemptAt
+	}
+	if dbDelivery.LastAttemptAt.Valid {
+		delivery.LastAttemptAt = &dbDelivery.LastAttemptAt.Time
+	}
+	if dbDelivery.ResponseStatus.Valid {
+		delivery.ResponseStatus = &dbDelivery.ResponseStatus.Int32
+	}
+	if dbDelivery.ResponseBody.Valid {
+		delivery.ResponseBody = &dbDelivery.ResponseBody.String
+	}
+	if dbDelivery.Error.Valid {
+		delivery.Error = &dbDelivery.Error.String
+	}
+	return delivery
+}
+
+// Method to get the authenticated user's webhook endpoint named in the path, responding
+// with an error and returning false if it isn't theirs
+func (cfg *apiConfig) ownWebhookEndpoint(w http.ResponseWriter, r *http.Request) (database.WebhookEndpoint, bool) {
+	endpointID, err := uuid.Parse(r.PathValue("endpointID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid endpoint ID", err)
+		return database.WebhookEndpoint{}, false
+	}
+	endpoint, err := cfg.db.GetWebhookEndpoint(r.Context(), endpointID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && endpoint.UserID != principalFrom(r).UserID) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find endpoint", err)
+		return database.WebhookEndpoint{}, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retreive endpoint", err)
+		return database.WebhookEndpoint{}, false
+	}
+	return endpoint, true
+}
+
+// Handler function to register a webhook endpoint for some of the authenticated user's events
+func (cfg *apiConfig) handlerWebhookEndpointsCreate(w http.ResponseWriter, r *http.Request) {
+
+	// Struct for JSON request parameters
+	type parameters struct {
+		URL        string   `json:"url"`
+		EventTypes []string `json:"event_types"`
+	}
+
+	// Struct for JSON response
+	type response struct {
+		WebhookEndpoint
+		Secret string `json:"secret"`
+	}
+
+	// Gather UserID of principal authenticated by middleware
+	userID := principalFrom(r).UserID
+
+	// Decode JSON and gather parameters
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	// Validate URL and event types. Plain HTTP is allowed in development for local receivers.
+	if len(params.URL) > 2048 {
+		respondWithError(w, http.StatusBadRequest, "Webhook URL is too long", nil)
+		return
+	}
+	err = webhook.ValidateURL(params.URL, cfg.platform == "dev")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if len(params.EventTypes) == 0 {
+		respondWithError(w, http.StatusBadRequest, "At least one event type is required", nil)
+		return
+	}
+	eventTypes := []string{}
+	for _, eventType := range params.EventTypes {
+		if !slices.Contains(database.WebhookEventTypes, eventType) {
+			respondWithError(w, http.StatusBadRequest, "Unknown event type: "+eventType, nil)
+			return
+		}
+		if !slices.Contains(eventTypes, eventType) {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+
+	// Limit how many endpoints each user can register
+	count, err := cfg.db.CountWebhookEndpoints(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't count endpoints", err)
+		return
+	}
+	if count >= maxWebhookEndpoints {
+		respondWithError(w, http.StatusConflict, "Too many webhook endpoints", nil)
+		return
+	}
+
+	// Generate the secret deliveries are signed with
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create secret", err)
+		return
+	}
+	dbEndpoint, err := cfg.db.CreateWebhookEndpoint(r.Context(), database.CreateWebhookEndpointParams{
+		UserID:     userID,
+		Url:        params.URL,
+		Secret:     secret,
+		EventTypes: eventTypes,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save endpoint", err)
+		return
+	}
+
+	// The secret is only ever shown here
+	respondWithJSON(w, http.StatusCreated, response{
+		WebhookEndpoint: webhookEndpointFromDB(dbEndpoint),
+		Secret:          secret,
+	})
+}
+
+// Handler function to list the authenticated user's webhook endpoints
+func (cfg *apiConfig) handlerWebhookEndpointsGet(w http.ResponseWriter, r *http.Request) {
+
+	// Struct for JSON response
+	type response struct {
+		Endpoints []WebhookEndpoint `json:"endpoints"`
+	}
+
+	// Gather UserID of principal authenticated by middleware
+	userID := principalFrom(r).UserID
+
+	// Retreive user's endpoints
+	dbEndpoints, err := cfg.db.ListWebhookEndpoints(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retreive endpoints", err)
+		return
+	}
+
+	endpoints := []WebhookEndpoint{}
+	for _, dbEndpoint := range dbEndpoints {
+		endpoints = append(endpoints, webhookEndpointFromDB(dbEndpoint))
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Endpoints: endpoints,
+	})
+}
+
+// Handler function to delete one of the authenticated user's webhook endpoints along with
+// its delivery log
+func (cfg *apiConfig) handlerWebhookEndpointsDelete(w http.ResponseWriter, r *http.Request) {
+
+	// Get specified endpoint ID
+	endpointID, err := uuid.Parse(r.PathValue("endpointID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid endpoint ID", err)
+		return
+	}
+
+	// Gather UserID of principal authenticated by middleware
+	userID := principalFrom(r).UserID
+
+	// Delete endpoint (only the owner's endpoints match)
+	n, err := cfg.db.DeleteWebhookEndpoint(r.Context(), database.DeleteWebhookEndpointParams{
+		ID:     endpointID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete endpoint", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't find endpoint", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Handler function to re-enable a webhook endpoint that was disabled after repeated
+// failures. Deliveries given up on while it was disabled can be sent again with redeliver.
+func (cfg *apiConfig) handlerWebhookEndpointsEnable(w http.ResponseWriter, r *http.Request) {
+
+	// Get specified endpoint ID
+	endpointID, err := uuid.Parse(r.PathValue("endpointID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid endpoint ID", err)
+		return
+	}
+
+	// Gather UserID of principal authenticated by middleware
+	userID := principalFrom(r).UserID
+
+	// Enable endpoint (only the owner's endpoints match)
+	dbEndpoint, err := cfg.db.EnableWebhookEndpoint(r.Context(), database.EnableWebhookEndpointParams{
+		ID:     endpointID,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find endpoint", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't enable endpoint", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, webhookEndpointFromDB(dbEndpoint))
+}
+
+// Handler function to page through the delivery log of one of the authenticated user's
+// webhook endpoints, newest first
+func (cfg *apiConfig) handlerWebhookDeliveriesGet(w http.ResponseWriter, r *http.Request) {
+
+	// Struct for paginated JSON response
+	type response struct {
+		Deliveries []WebhookDelivery `json:"deliveries"`
+		NextCursor *string           `json:"next_cursor"`
+	}
+
+	// Gather and validate limit and cursor parameters
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	endpoint, ok := cfg.ownWebhookEndpoint(w, r)
+	if !ok {
+		return
+	}
+
+	dbDeliveries, err := cfg.db.ListWebhookDeliveries(r.Context(), database.ListWebhookDeliveriesParams{
+		EndpointID:     endpoint.ID,
+		AfterCreatedAt: page.afterCreatedAt(),
+		AfterID:        page.afterID(),
+		PageSize:       page.fetchSize(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retreive deliveries", err)
+		return
+	}
+
+	// If an extra row was returned there is another page
+	var nextCursor *string
+	if len(dbDeliveries) > int(page.Limit) {
+		dbDeliveries = dbDeliveries[:page.Limit]
+		last := dbDeliveries[len(dbDeliveries)-1]
+		cursor := encodeCursor(last.CreatedAt, last.ID)
+		nextCursor = &cursor
+	}
+
+	deliveries := []WebhookDelivery{}
+	for _, dbDelivery := range dbDeliveries {
+		deliveries = append(deliveries, webhookDeliveryFromDB(dbDelivery))
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Deliveries: deliveries,
+		NextCursor: nextCursor,
+	})
+}
+
+// Handler function to queue a fresh delivery of a past event to the same endpoint
+func (cfg *apiConfig) handlerWebhookDeliveriesRedeliver(w http.ResponseWriter, r *http.Request) {
+
+	// Get specified delivery ID
+	deliveryID, err := uuid.Parse(r.PathValue("deliveryID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid delivery ID", err)
+		return
+	}
+
+	endpoint, ok := cfg.ownWebhookEndpoint(w, r)
+	if !ok {
+		return
+	}
+
+	// Copy the delivery's event into a new pending delivery, keeping the same event ID so
+	// receivers can recognize duplicates
+	dbDelivery, err := cfg.db.RedeliverWebhookTx(r.Context(), database.RedeliverWebhookParams{
+		ID:         deliveryID,
+		EndpointID: endpoint.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find delivery", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't queue delivery", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusAccepted, webhookDeliveryFromDB(dbDelivery))
+}
@@ -4,58 +4,147 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
+	"time"
 
-	"chirpy/internal/auth"
+	"chirpy/internal/database"
+	"chirpy/internal/webhook"
 
 	"github.com/google/uuid"
 )
 
-// Handler function for webhook once user upgrades to chirpy red via polka payment
+// Polka signs each delivery in this header; deliveries older than the tolerance are refused
+const (
+	polkaSignatureHeader    = "Polka-Signature"
+	polkaSignatureTolerance = 5 * time.Minute
+	maxWebhookBodyBytes     = 1 << 20
+)
+
+// Handler function for Polka payment webhooks, which drive the Chirpy Red subscription
+// lifecycle. Deliveries must be signed, and each event is recorded by ID so a redelivered
+// event is only applied once.
 func (cfg *apiConfig) handlerWebhook(w http.ResponseWriter, r *http.Request) {
 
 	// Struct for JSON request parameters
 	type parameters struct {
+		ID    string `json:"id"`
 		Event string `json:"event"`
 		Data  struct {
-			UserID uuid.UUID `json:"user_id"`
+			UserID           uuid.UUID  `json:"user_id"`
+			Plan             string     `json:"plan"`
+			CurrentPeriodEnd *time.Time `json:"current_period_end"`
 		}
 	}
 
-	// Gather and validate Polka API Key
-	apiKey, err := auth.GetAPIKey(r.Header)
+	// Read raw body, as the signature covers the exact bytes sent
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodyBytes))
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't find api key", err)
+		respondWithError(w, http.StatusBadRequest, "Couldn't read body", err)
 		return
 	}
-	if apiKey != cfg.polkaKey {
-		respondWithError(w, http.StatusUnauthorized, "API key is invalid", err)
+
+	// Verify signature and timestamp before trusting anything in the body
+	err = webhook.Verify(cfg.polkaSecret, r.Header.Get(polkaSignatureHeader), body, polkaSignatureTolerance, time.Now())
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid webhook signature", err)
 		return
 	}
 
 	// Decode JSON and gather parameters
-	decoder := json.NewDecoder(r.Body)
 	params := parameters{}
-	err = decoder.Decode(&params)
+	err = json.Unmarshal(body, &params)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	if params.ID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing event ID", nil)
+		return
+	}
+
+	// Store payload, or count the redelivery of one already stored
+	event, err := cfg.db.RecordWebhookEvent(r.Context(), database.RecordWebhookEventParams{
+		ID:        params.ID,
+		EventType: params.Event,
+		Payload:   body,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't record webhook event", err)
+		return
+	}
+	if event.Done() {
+		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 
-	// Verify if user has been upgraded
-	if params.Event != "user.upgraded" {
+	// Choose how the event changes the user's subscription, recording events we don't
+	// handle as ignored
+	userID := params.Data.UserID
+	var apply func(q *database.Queries) error
+	switch params.Event {
+	case "user.upgraded", "subscription.renewed":
+		plan := params.Data.Plan
+		if plan == "" {
+			plan = database.PlanChirpyRed
+		}
+		periodEnd := sql.NullTime{}
+		if params.Data.CurrentPeriodEnd != nil {
+			periodEnd = sql.NullTime{Time: params.Data.CurrentPeriodEnd.UTC(), Valid: true}
+		}
+		apply = func(q *database.Queries) error {
+			_, err := database.ActivateSubscription(r.Context(), q, userID, plan, periodEnd)
+			return err
+		}
+	case "payment.failed":
+		apply = func(q *database.Queries) error {
+			_, err := database.MarkSubscriptionPastDue(r.Context(), q, userID)
+			return err
+		}
+	case "user.downgraded":
+		apply = func(q *database.Queries) error {
+			_, err := database.CancelSubscription(r.Context(), q, userID, time.Now().UTC())
+			return err
+		}
+	case "payment.refunded":
+		apply = func(q *database.Queries) error {
+			_, err := database.ExpireSubscription(r.Context(), q, userID)
+			return err
+		}
+	default:
+		err = cfg.db.SetWebhookEventOutcome(r.Context(), database.SetWebhookEventOutcomeParams{
+			ID:     params.ID,
+			Status: database.WebhookEventStatusIgnored,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't record webhook event", err)
+			return
+		}
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 
-	// Retreive user data, set chirpy red upgrade as true, and save to database
-	_, err = cfg.db.UpgradeToChirpyRed(r.Context(), params.Data.UserID)
+	err = cfg.db.ProcessWebhookEventTx(r.Context(), params.ID, apply)
+	if errors.Is(err, database.ErrWebhookEventDone) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
 	if err != nil {
+		// Record the failure for debugging; Polka retries until a delivery succeeds
+		outcomeErr := cfg.db.SetWebhookEventOutcome(r.Context(), database.SetWebhookEventOutcomeParams{
+			ID:     params.ID,
+			Status: database.WebhookEventStatusFailed,
+			Error:  sql.NullString{String: err.Error(), Valid: true},
+		})
+		if outcomeErr != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't record webhook event", outcomeErr)
+			return
+		}
 		if errors.Is(err, sql.ErrNoRows) {
-			respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+			respondWithError(w, http.StatusNotFound, "Couldn't find user or subscription", err)
 			return
 		}
-		respondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't process webhook event", err)
 		return
 	}
 
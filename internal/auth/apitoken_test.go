@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Unit tests to check requested scopes are validated and normalized
+func TestParseScopes(t *testing.T) {
+
+	// Create a struct for test data
+	tests := []struct {
+		name      string
+		requested []string
+		want      []string
+		wantErr   bool
+	}{
+		// Test 1
+		{
+			name:      "Single scope",
+			requested: []string{"chirps:write"},
+			want:      []string{"chirps:write"},
+			wantErr:   false,
+		},
+
+		// Test 2
+		{
+			name:      "Duplicates removed and canonical order",
+			requested: []string{"profile:write", "chirps:read", "profile:write"},
+			want:      []string{"chirps:read", "profile:write"},
+			wantErr:   false,
+		},
+
+		// Test 3
+		{
+			name:      "Unknown scope",
+			requested: []string{"chirps:read", "admin"},
+			wantErr:   true,
+		},
+
+		// Test 4
+		{
+			name:      "No scopes",
+			requested: []string{},
+			wantErr:   true,
+		},
+	}
+
+	// Loop through test cases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseScopes(tt.requested)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseScopes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseScopes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Unit test to check personal access tokens are recognizable and hash consistently
+func TestPersonalToken(t *testing.T) {
+	token, err := MakePersonalToken()
+	if err != nil {
+		t.Fatalf("MakePersonalToken() error = %v", err)
+	}
+	if !IsPersonalToken(token) {
+		t.Errorf("IsPersonalToken(%q) = false", token)
+	}
+	if IsPersonalToken("eyJhbGciOiJIUzI1NiJ9.e30.sig") {
+		t.Errorf("IsPersonalToken() accepted a JWT")
+	}
+	if HashPersonalToken(token) != HashPersonalToken(token) || HashPersonalToken(token) == token {
+		t.Errorf("HashPersonalToken() is not a stable hash")
+	}
+	if hint := PersonalTokenHint(token); len(hint) != 4 || token[len(token)-4:] != hint {
+		t.Errorf("PersonalTokenHint() = %q", hint)
+	}
+	if !HasScope([]string{"chirps:read"}, ScopeChirpsRead) || HasScope([]string{"chirps:read"}, ScopeChirpsWrite) {
+		t.Errorf("HasScope() returned wrong result")
+	}
+}
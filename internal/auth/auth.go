@@ -2,6 +2,7 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -11,51 +12,87 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type TokenType string
 
-// TokenTypeAccess
+// TokenTypeAccess, TokenTypeMFA
 const (
 	TokenTypeAccess TokenType = "chirpy-access"
+	// Short-lived token proving the password step of a two-step login
+	TokenTypeMFA TokenType = "chirpy-mfa"
 )
 
+// Refresh tokens are valid for 60 days, and every use exchanges them for a new one
+const RefreshTokenExpiresIn = 60 * 24 * time.Hour
+
 // ErrNoAuthHeaderIncluded
 var ErrNoAuthHeaderIncluded = errors.New("no auth header included in request")
 
-// Function to hash password
-func HashPassword(password string) (string, error) {
-	dat, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", err
-	}
-	return string(dat), nil
+// Function to create a JWT (JSON WEb Token)
+func MakeJWT(userID uuid.UUID, keys *Keyring, expiresIn time.Duration) (string, error) {
+	return makeToken(TokenTypeAccess, userID, keys, expiresIn)
 }
 
-// Function to check password hash
-func CheckPasswordHash(password, hash string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+// Function to create an MFA challenge token, exchanged for access and refresh tokens once
+// a second factor is verified
+func MakeMFAToken(userID uuid.UUID, keys *Keyring, expiresIn time.Duration) (string, error) {
+	return makeToken(TokenTypeMFA, userID, keys, expiresIn)
 }
 
-// Function to create a JWT (JSON WEb Token)
-func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
-
-	// Create signing key with token secret
-	signingKey := []byte(tokenSecret)
-
-	// Create a token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		Issuer:    string(TokenTypeAccess),
+// Function to create a JWT of the given type, signed with the keyring's active key
+func makeToken(tokenType TokenType, userID uuid.UUID, keys *Keyring, expiresIn time.Duration) (string, error) {
+	return keys.sign(jwt.RegisteredClaims{
+		Issuer:    string(tokenType),
 		IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
 		ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
 		Subject:   userID.String(),
 	})
-	return token.SignedString(signingKey)
+}
+
+// Struct for the validated claims of an access token
+type AccessClaims struct {
+	UserID   uuid.UUID
+	IssuedAt time.Time
+}
+
+// Function to get the cutoff stored when a user's tokens are revoked. Token times are
+// whole seconds, so the cutoff is rounded up to the next second to also catch tokens
+// issued earlier in the same second.
+func RevocationCutoff(now time.Time) time.Time {
+	return now.UTC().Truncate(time.Second).Add(time.Second)
+}
+
+// Method to check whether a token was issued before a revocation cutoff
+func (c AccessClaims) RevokedBy(validAfter time.Time) bool {
+	return c.IssuedAt.Before(validAfter)
 }
 
 // Function to validate JWT
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
+func ValidateJWT(tokenString string, keys *Keyring) (uuid.UUID, error) {
+	claims, err := ParseAccessToken(tokenString, keys)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return claims.UserID, nil
+}
+
+// Function to validate JWT and return its user ID and issue time
+func ParseAccessToken(tokenString string, keys *Keyring) (AccessClaims, error) {
+	return parseToken(tokenString, keys, TokenTypeAccess)
+}
+
+// Function to validate an MFA challenge token
+func ValidateMFAToken(tokenString string, keys *Keyring) (uuid.UUID, error) {
+	claims, err := parseToken(tokenString, keys, TokenTypeMFA)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return claims.UserID, nil
+}
+
+// Function to validate a JWT of the given type, verified with the keyring key named by its kid
+func parseToken(tokenString string, keys *Keyring, tokenType TokenType) (AccessClaims, error) {
 
 	// Create a claims struct
 	claimsStruct := jwt.RegisteredClaims{}
@@ -64,36 +101,48 @@ func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
 	token, err := jwt.ParseWithClaims(
 		tokenString,
 		&claimsStruct,
-		func(token *jwt.Token) (interface{}, error) { return []byte(tokenSecret), nil },
+		keys.keyFunc,
 	)
 	if err != nil {
-		return uuid.Nil, err
+		return AccessClaims{}, err
 	}
 
 	// Get user ID string
 	userIDString, err := token.Claims.GetSubject()
 	if err != nil {
-		return uuid.Nil, err
+		return AccessClaims{}, err
 	}
 
 	// Get issuer
 	issuer, err := token.Claims.GetIssuer()
 	if err != nil {
-		return uuid.Nil, err
+		return AccessClaims{}, err
 	}
 
 	//Validate issuer
-	if issuer != string(TokenTypeAccess) {
-		return uuid.Nil, errors.New("invalid issuer")
+	if issuer != string(tokenType) {
+		return AccessClaims{}, errors.New("invalid issuer")
 	}
 
 	// Validate user ID
 	id, err := uuid.Parse(userIDString)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("invalid user ID: %w", err)
+		return AccessClaims{}, fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	// Get issue time
+	issuedAt, err := token.Claims.GetIssuedAt()
+	if err != nil {
+		return AccessClaims{}, err
+	}
+	if issuedAt == nil {
+		return AccessClaims{}, errors.New("missing issued at")
 	}
 
-	return id, nil
+	return AccessClaims{
+		UserID:   id,
+		IssuedAt: issuedAt.Time,
+	}, nil
 }
 
 // Function to get BearerToken
@@ -114,7 +163,8 @@ func GetBearerToken(headers http.Header) (string, error) {
 	return splitAuth[1], nil
 }
 
-// Function to make a random 256 bit token encoded in hex
+// Function to make a random 256 bit token encoded in hex. Each refresh token is used once:
+// refreshing rotates it for a new one in the same token family.
 func MakeRefreshToken() (string, error) {
 	token := make([]byte, 32)
 	_, err := rand.Read(token)
@@ -124,6 +174,23 @@ func MakeRefreshToken() (string, error) {
 	return hex.EncodeToString(token), nil
 }
 
+// Function to make a random 256 bit single-use token for a link sent by email
+func MakeEmailToken() (string, error) {
+	token := make([]byte, 32)
+	_, err := rand.Read(token)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// Function to hash an emailed token for storage, so a database leak can't be used to
+// verify addresses or reset passwords
+func HashEmailToken(token string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	return hex.EncodeToString(sum[:])
+}
+
 // Function to extract api key from authorization header
 func GetAPIKey(headers http.Header) (string, error) {
 
@@ -81,7 +81,7 @@ func TestValidateJWT(t *testing.T) {
 
 	// Generate a JWT for a unique user
 	userID := uuid.New()
-	validToken, _ := MakeJWT(userID, "secret", time.Hour)
+	validToken, _ := MakeJWT(userID, hmacKeyring("secret"), time.Hour)
 
 	// Create a struct for test data
 	tests := []struct {
@@ -122,7 +122,7 @@ func TestValidateJWT(t *testing.T) {
 	// Iterate through each test
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotUserID, err := ValidateJWT(tt.tokenString, tt.tokenSecret)
+			gotUserID, err := ValidateJWT(tt.tokenString, hmacKeyring(tt.tokenSecret))
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ValidateJWT() error =%v, wantErr %v", err, tt.wantErr)
 				return
@@ -134,6 +134,105 @@ func TestValidateJWT(t *testing.T) {
 	}
 }
 
+// Unit test to check MFA challenge tokens and access tokens can't be swapped
+func TestMFAToken(t *testing.T) {
+	userID := uuid.New()
+	keys := hmacKeyring("secret")
+	mfaToken, _ := MakeMFAToken(userID, keys, time.Minute)
+	accessToken, _ := MakeJWT(userID, keys, time.Minute)
+
+	gotUserID, err := ValidateMFAToken(mfaToken, keys)
+	if err != nil || gotUserID != userID {
+		t.Errorf("ValidateMFAToken() = %v, %v, want %v", gotUserID, err, userID)
+	}
+	if _, err := ValidateJWT(mfaToken, keys); err == nil {
+		t.Errorf("ValidateJWT() accepted an MFA token")
+	}
+	if _, err := ValidateMFAToken(accessToken, keys); err == nil {
+		t.Errorf("ValidateMFAToken() accepted an access token")
+	}
+}
+
+// Unit test to check access token issue time is returned
+func TestParseAccessToken(t *testing.T) {
+	userID := uuid.New()
+	before := time.Now().Truncate(time.Second)
+	keys := hmacKeyring("secret")
+	token, _ := MakeJWT(userID, keys, time.Hour)
+
+	claims, err := ParseAccessToken(token, keys)
+	if err != nil {
+		t.Fatalf("ParseAccessToken() error = %v", err)
+	}
+	if claims.UserID != userID {
+		t.Errorf("ParseAccessToken() UserID = %v, want %v", claims.UserID, userID)
+	}
+	if claims.IssuedAt.Before(before) || claims.IssuedAt.After(time.Now()) {
+		t.Errorf("ParseAccessToken() IssuedAt = %v, want around %v", claims.IssuedAt, before)
+	}
+}
+
+// Unit tests to check tokens are revoked by a cutoff only if issued before it
+func TestRevokedBy(t *testing.T) {
+	revokedAt := time.Date(2025, 1, 1, 12, 0, 0, 500_000_000, time.UTC)
+	cutoff := RevocationCutoff(revokedAt)
+
+	// Create a struct for test data
+	tests := []struct {
+		name        string
+		issuedAt    time.Time
+		wantRevoked bool
+	}{
+		// Test 1
+		{
+			name:        "Issued in an earlier second",
+			issuedAt:    revokedAt.Add(-time.Minute).Truncate(time.Second),
+			wantRevoked: true,
+		},
+
+		// Test 2
+		{
+			name:        "Issued in the same second",
+			issuedAt:    revokedAt.Truncate(time.Second),
+			wantRevoked: true,
+		},
+
+		// Test 3
+		{
+			name:        "Issued in the next second",
+			issuedAt:    revokedAt.Truncate(time.Second).Add(time.Second),
+			wantRevoked: false,
+		},
+	}
+
+	// Loop through test cases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := AccessClaims{UserID: uuid.New(), IssuedAt: tt.issuedAt}
+			if got := claims.RevokedBy(cutoff); got != tt.wantRevoked {
+				t.Errorf("RevokedBy() = %v, want %v", got, tt.wantRevoked)
+			}
+		})
+	}
+}
+
+// Unit tests to check issued tokens keep whole-second times that RevokedBy compares correctly
+func TestRevokedByIssuedToken(t *testing.T) {
+	keys := hmacKeyring("secret")
+
+	token, err := MakeJWT(uuid.New(), keys, time.Hour)
+	if err != nil {
+		t.Fatalf("MakeJWT() error = %v", err)
+	}
+	claims, err := ParseAccessToken(token, keys)
+	if err != nil {
+		t.Fatalf("ParseAccessToken() error = %v", err)
+	}
+	if !claims.RevokedBy(RevocationCutoff(time.Now())) {
+		t.Errorf("RevokedBy() = false for a token issued before revoking, want true")
+	}
+}
+
 // Unit tests to check BearerToken is gathered
 func TestGetBearerToken(t *testing.T) {
 
@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Function to create a keyring holding only a legacy HS256 secret
+func hmacKeyring(secret string) *Keyring {
+	keys, _ := NewKeyring(NewHMACKey("", secret))
+	return keys
+}
+
+// Function to generate PEM encoded RSA and Ed25519 private keys for tests
+func testKeyPEMs(t *testing.T) (rsaPEM, edPEM []byte) {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() error = %v", err)
+	}
+	rsaPEM = pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(rsaKey),
+	})
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("ed25519.GenerateKey() error = %v", err)
+	}
+	dat, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+	}
+	edPEM = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: dat})
+	return rsaPEM, edPEM
+}
+
+// Unit tests to check tokens signed by each key type validate against the keyring
+func TestKeyringSignAndValidate(t *testing.T) {
+	rsaPEM, edPEM := testKeyPEMs(t)
+	rsaKey, err := ParseKeyPEM("rsa-1", rsaPEM)
+	if err != nil {
+		t.Fatalf("ParseKeyPEM() error = %v", err)
+	}
+	edKey, err := ParseKeyPEM("ed-1", edPEM)
+	if err != nil {
+		t.Fatalf("ParseKeyPEM() error = %v", err)
+	}
+
+	// Create a struct for test data
+	tests := []struct {
+		name    string
+		key     *Key
+		wantAlg string
+	}{
+		// Test 1
+		{
+			name:    "RSA key",
+			key:     rsaKey,
+			wantAlg: "RS256",
+		},
+
+		// Test 2
+		{
+			name:    "Ed25519 key",
+			key:     edKey,
+			wantAlg: "EdDSA",
+		},
+
+		// Test 3
+		{
+			name:    "HMAC key with kid",
+			key:     NewHMACKey("hmac-1", "secret"),
+			wantAlg: "HS256",
+		},
+	}
+
+	// Loop through test cases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := NewKeyring(tt.key)
+			if err != nil {
+				t.Fatalf("NewKeyring() error = %v", err)
+			}
+			userID := uuid.New()
+			token, err := MakeJWT(userID, keys, time.Hour)
+			if err != nil {
+				t.Fatalf("MakeJWT() error = %v", err)
+			}
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &jwt.RegisteredClaims{})
+			if err != nil {
+				t.Fatalf("ParseUnverified() error = %v", err)
+			}
+			if parsed.Header["kid"] != tt.key.ID || parsed.Method.Alg() != tt.wantAlg {
+				t.Errorf("token header kid = %v alg = %v, want %v %v", parsed.Header["kid"], parsed.Method.Alg(), tt.key.ID, tt.wantAlg)
+			}
+
+			gotUserID, err := ValidateJWT(token, keys)
+			if err != nil || gotUserID != userID {
+				t.Errorf("ValidateJWT() = %v, %v, want %v", gotUserID, err, userID)
+			}
+		})
+	}
+}
+
+// Unit test to check tokens signed by a retired key stay valid after rotation
+func TestKeyringRotation(t *testing.T) {
+	rsaPEM, edPEM := testKeyPEMs(t)
+	oldKey, _ := ParseKeyPEM("old", rsaPEM)
+	newKey, _ := ParseKeyPEM("new", edPEM)
+	legacy := NewHMACKey("", "secret")
+
+	userID := uuid.New()
+	before, _ := NewKeyring(oldKey)
+	oldToken, _ := MakeJWT(userID, before, time.Hour)
+	legacyToken, _ := MakeJWT(userID, hmacKeyring("secret"), time.Hour)
+
+	after, err := NewKeyring(newKey, oldKey, legacy)
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	if _, err := ValidateJWT(oldToken, after); err != nil {
+		t.Errorf("ValidateJWT() rejected token from retired key: %v", err)
+	}
+	if _, err := ValidateJWT(legacyToken, after); err != nil {
+		t.Errorf("ValidateJWT() rejected legacy token without kid: %v", err)
+	}
+
+	// Once a key is dropped its tokens are rejected
+	dropped, _ := NewKeyring(newKey)
+	if _, err := ValidateJWT(oldToken, dropped); err == nil {
+		t.Errorf("ValidateJWT() accepted token from removed key")
+	}
+}
+
+// Unit test to check a token can't switch algorithm to use a public key as an HMAC secret
+func TestKeyringRejectsAlgorithmMismatch(t *testing.T) {
+	rsaPEM, _ := testKeyPEMs(t)
+	rsaKey, _ := ParseKeyPEM("rsa-1", rsaPEM)
+	keys, _ := NewKeyring(rsaKey)
+
+	jwk := keys.JWKS().Keys[0]
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    string(TokenTypeAccess),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		Subject:   uuid.New().String(),
+	})
+	token.Header["kid"] = "rsa-1"
+	forged, _ := token.SignedString([]byte(jwk.N))
+
+	if _, err := ValidateJWT(forged, keys); err == nil {
+		t.Errorf("ValidateJWT() accepted token with mismatched algorithm")
+	}
+}
+
+// Unit test to check the JWKS publishes public keys only
+func TestKeyringJWKS(t *testing.T) {
+	rsaPEM, edPEM := testKeyPEMs(t)
+	rsaKey, _ := ParseKeyPEM("rsa-1", rsaPEM)
+	edKey, _ := ParseKeyPEM("ed-1", edPEM)
+	keys, err := NewKeyring(rsaKey, edKey, NewHMACKey("", "secret"))
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+
+	jwks := keys.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("JWKS() returned %d keys, want 2", len(jwks.Keys))
+	}
+	for _, jwk := range jwks.Keys {
+		switch jwk.Kid {
+		case "rsa-1":
+			if jwk.Kty != "RSA" || jwk.Alg != "RS256" || jwk.N == "" || jwk.E != "AQAB" {
+				t.Errorf("JWKS() RSA key = %+v", jwk)
+			}
+		case "ed-1":
+			if jwk.Kty != "OKP" || jwk.Crv != "Ed25519" || jwk.Alg != "EdDSA" || jwk.X == "" {
+				t.Errorf("JWKS() Ed25519 key = %+v", jwk)
+			}
+		default:
+			t.Errorf("JWKS() unexpected key %q", jwk.Kid)
+		}
+	}
+
+	// A verify-only public key can't be the active signing key
+	pub, _ := x509.MarshalPKIXPublicKey(edKey.verifyKey)
+	pubKey, err := ParseKeyPEM("ed-pub", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}))
+	if err != nil {
+		t.Fatalf("ParseKeyPEM() error = %v", err)
+	}
+	if _, err := NewKeyring(pubKey); err == nil {
+		t.Errorf("NewKeyring() accepted a public key as the active key")
+	}
+}
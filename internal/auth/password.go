@@ -0,0 +1,309 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrPasswordMismatch is returned when a password doesn't match its hash
+var ErrPasswordMismatch = errors.New("password doesn't match hash")
+
+// ErrUnknownPasswordHash is returned when no hasher recognises a stored hash
+var ErrUnknownPasswordHash = errors.New("unknown password hash format")
+
+// PasswordHasher hashes passwords in one format and checks hashes in that format
+type PasswordHasher interface {
+	// Hash a password with the hasher's current parameters
+	Hash(password string) (string, error)
+	// Check a password against a hash, returning ErrPasswordMismatch if it doesn't match
+	Verify(password, hash string) error
+	// Report whether the hash is in this hasher's format
+	Recognizes(hash string) bool
+	// Report whether a hash in this hasher's format used weaker parameters than current
+	NeedsRehash(hash string) bool
+}
+
+// Argon2idHasher hashes passwords with Argon2id, encoded in the PHC string format
+// $argon2id$v=19$m=<KiB>,t=<iterations>,p=<threads>$<salt>$<key>
+type Argon2idHasher struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// Function to create an Argon2id hasher with the parameters OWASP recommends
+func NewArgon2idHasher() Argon2idHasher {
+	return Argon2idHasher{
+		Memory:      19 * 1024,
+		Iterations:  2,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// Method to hash a password with a random salt
+func (h Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Memory, h.Iterations, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Method to check a password against an Argon2id hash, using the parameters stored in it
+func (h Argon2idHasher) Verify(password, hash string) error {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return err
+	}
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+// Method to report whether a hash is in Argon2id PHC format
+func (h Argon2idHasher) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+// Method to report whether a hash used less memory, fewer iterations or a shorter key
+// than the hasher's current parameters
+func (h Argon2idHasher) NeedsRehash(hash string) bool {
+	params, _, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+	return params.Memory < h.Memory || params.Iterations < h.Iterations ||
+		params.Parallelism < h.Parallelism || uint32(len(key)) < h.KeyLength
+}
+
+// Function to decode the parameters, salt and key of an Argon2id PHC string
+func decodeArgon2id(hash string) (Argon2idHasher, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return Argon2idHasher{}, nil, nil, ErrUnknownPasswordHash
+	}
+	var version int
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return Argon2idHasher{}, nil, nil, errors.New("unsupported argon2 version")
+	}
+	params := Argon2idHasher{}
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil {
+		return Argon2idHasher{}, nil, nil, fmt.Errorf("invalid argon2 parameters: %w", err)
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2idHasher{}, nil, nil, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return Argon2idHasher{}, nil, nil, err
+	}
+	return params, salt, key, nil
+}
+
+// BcryptHasher hashes passwords with bcrypt. Passwords over 72 bytes are refused rather
+// than silently truncated.
+type BcryptHasher struct {
+	Cost int
+}
+
+// Method to hash a password with bcrypt
+func (h BcryptHasher) Hash(password string) (string, error) {
+	dat, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(dat), nil
+}
+
+// Method to check a password against a bcrypt hash
+func (h BcryptHasher) Verify(password, hash string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrPasswordMismatch
+	}
+	return err
+}
+
+// Method to report whether a hash is in bcrypt's modular crypt format
+func (h BcryptHasher) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+// Method to report whether a bcrypt hash used a lower cost than the hasher
+func (h BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost < h.Cost
+}
+
+// PasswordHashers hashes new passwords with its current hasher and checks stored hashes
+// with whichever hasher recognizes them, so older formats keep working until users log in
+// and their hashes are upgraded
+type PasswordHashers struct {
+	current PasswordHasher
+	others  []PasswordHasher
+	dummy   func() string
+}
+
+// Function to create a set of hashers. Others are only used to check existing hashes.
+func NewPasswordHashers(current PasswordHasher, others ...PasswordHasher) *PasswordHashers {
+	h := &PasswordHashers{
+		current: current,
+		others:  others,
+	}
+
+	// Hash of a random password, checked against when a login names an unknown user. Older
+	// formats can be slower to check than the current one (bcrypt at cost 10 takes longer
+	// than Argon2id at OWASP settings), so the slowest format is used.
+	h.dummy = sync.OnceValue(func() string {
+		return slowestHash(append([]PasswordHasher{current}, others...))
+	})
+	return h
+}
+
+// Function to hash a random password with each hasher and return the hash that took
+// longest to check
+func slowestHash(hashers []PasswordHasher) string {
+	token, _ := MakeRefreshToken()
+	var slowest string
+	var longest time.Duration
+	for _, hasher := range hashers {
+		hash, err := hasher.Hash(token)
+		if err != nil {
+			continue
+		}
+		start := time.Now()
+		hasher.Verify(token, hash)
+		elapsed := time.Since(start)
+		if elapsed > longest {
+			slowest = hash
+			longest = elapsed
+		}
+	}
+	return slowest
+}
+
+// Hashers used when none are configured: Argon2id for new hashes, accepting bcrypt hashes
+// from before the upgrade
+var DefaultPasswordHashers = NewPasswordHashers(NewArgon2idHasher(), BcryptHasher{Cost: bcrypt.DefaultCost})
+
+// Method to hash a password with the current hasher
+func (h *PasswordHashers) Hash(password string) (string, error) {
+	return h.current.Hash(password)
+}
+
+// Method to check a password against a stored hash. On a match it also reports whether
+// the hash should be replaced because it uses an older format or weaker parameters.
+func (h *PasswordHashers) Check(password, hash string) (rehash bool, err error) {
+	for i, hasher := range append([]PasswordHasher{h.current}, h.others...) {
+		if !hasher.Recognizes(hash) {
+			continue
+		}
+		err := hasher.Verify(password, hash)
+		if err != nil {
+			return false, err
+		}
+		return i > 0 || hasher.NeedsRehash(hash), nil
+	}
+	return false, ErrUnknownPasswordHash
+}
+
+// Method to spend as long as Check would when there is no user to check a password
+// against, so response times don't reveal which accounts exist
+func (h *PasswordHashers) EqualizeCheck(password string) {
+	h.Check(password, h.dummy())
+}
+
+// Function to hash password with the default hashers
+func HashPassword(password string) (string, error) {
+	return DefaultPasswordHashers.Hash(password)
+}
+
+// Function to check password hash with the default hashers
+func CheckPasswordHash(password, hash string) error {
+	_, err := DefaultPasswordHashers.Check(password, hash)
+	return err
+}
+
+// PasswordPolicy sets the rules new passwords must follow
+type PasswordPolicy struct {
+	MinLength int
+	MaxLength int
+	// Passwords known from breaches, which are refused whatever their length
+	Breached map[string]struct{}
+}
+
+// Function to create a policy following NIST SP 800-63B: at least 8 characters and up to
+// 128, with no composition rules
+func NewPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength: 8,
+		MaxLength: 128,
+	}
+}
+
+// Method to check a new password against the policy, returning an error suitable for
+// showing the user
+func (p PasswordPolicy) Validate(password string) error {
+	n := utf8.RuneCountInString(password)
+	if n < p.MinLength {
+		return fmt.Errorf("Password must be at least %d characters", p.MinLength)
+	}
+	if p.MaxLength > 0 && n > p.MaxLength {
+		return fmt.Errorf("Password must be at most %d characters", p.MaxLength)
+	}
+	if _, ok := p.Breached[password]; ok {
+		return errors.New("Password has appeared in a data breach, please choose another")
+	}
+	return nil
+}
+
+// Function to load a breached password list with one password per line. Blank lines are
+// skipped.
+func LoadBreachedPasswords(path string) (map[string]struct{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	breached := map[string]struct{}{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		password := strings.TrimRight(scanner.Text(), "\r")
+		if password != "" {
+			breached[password] = struct{}{}
+		}
+	}
+	err = scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+	return breached, nil
+}
@@ -0,0 +1,239 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Unit tests to check stored hashes are checked by the right hasher and flagged for rehashing
+func TestPasswordHashersCheck(t *testing.T) {
+
+	// Hashers with cheap parameters so the tests run quickly
+	current := Argon2idHasher{Memory: 1024, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	weaker := Argon2idHasher{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	legacy := BcryptHasher{Cost: bcrypt.MinCost}
+	hashers := NewPasswordHashers(current, legacy)
+
+	password := "correct horse battery staple"
+	currentHash, _ := current.Hash(password)
+	weakerHash, _ := weaker.Hash(password)
+	legacyHash, _ := legacy.Hash(password)
+
+	// Create a struct for test data
+	tests := []struct {
+		name       string
+		password   string
+		hash       string
+		wantRehash bool
+		wantErr    error
+	}{
+		// Test 1
+		{
+			name:     "Current hash",
+			password: password,
+			hash:     currentHash,
+		},
+
+		// Test 2
+		{
+			name:       "Argon2id hash with fewer iterations",
+			password:   password,
+			hash:       weakerHash,
+			wantRehash: true,
+		},
+
+		// Test 3
+		{
+			name:       "Legacy bcrypt hash",
+			password:   password,
+			hash:       legacyHash,
+			wantRehash: true,
+		},
+
+		// Test 4
+		{
+			name:     "Wrong password against Argon2id",
+			password: "wrong",
+			hash:     currentHash,
+			wantErr:  ErrPasswordMismatch,
+		},
+
+		// Test 5
+		{
+			name:     "Wrong password against bcrypt",
+			password: "wrong",
+			hash:     legacyHash,
+			wantErr:  ErrPasswordMismatch,
+		},
+
+		// Test 6
+		{
+			name:     "Unknown hash format",
+			password: password,
+			hash:     "$md5$abc",
+			wantErr:  ErrUnknownPasswordHash,
+		},
+	}
+
+	// Loop through test cases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rehash, err := hashers.Check(tt.password, tt.hash)
+			if err != tt.wantErr {
+				t.Fatalf("Check() err = %v, want %v", err, tt.wantErr)
+			}
+			if rehash != tt.wantRehash {
+				t.Errorf("Check() rehash = %v, want %v", rehash, tt.wantRehash)
+			}
+		})
+	}
+}
+
+// Unit tests to check unknown users are checked against the format slowest to check
+func TestPasswordHashersDummy(t *testing.T) {
+	fastArgon2id := Argon2idHasher{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	slowArgon2id := Argon2idHasher{Memory: 64 * 1024, Iterations: 4, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	fastBcrypt := BcryptHasher{Cost: bcrypt.MinCost}
+	slowBcrypt := BcryptHasher{Cost: 12}
+
+	// Create a struct for test data
+	tests := []struct {
+		name    string
+		hashers *PasswordHashers
+		want    PasswordHasher
+	}{
+		// Test 1
+		{
+			name:    "Legacy bcrypt slower than current Argon2id",
+			hashers: NewPasswordHashers(fastArgon2id, slowBcrypt),
+			want:    slowBcrypt,
+		},
+
+		// Test 2
+		{
+			name:    "Current Argon2id slower than legacy bcrypt",
+			hashers: NewPasswordHashers(slowArgon2id, fastBcrypt),
+			want:    slowArgon2id,
+		},
+	}
+
+	// Loop through test cases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dummy := tt.hashers.dummy()
+			if !tt.want.Recognizes(dummy) {
+				t.Errorf("dummy() = %q, want hash from %T", dummy, tt.want)
+			}
+		})
+	}
+}
+
+// Unit tests to check Argon2id hashes are PHC strings recording their parameters
+func TestArgon2idHasherFormat(t *testing.T) {
+	hasher := Argon2idHasher{Memory: 1024, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	hash, err := hasher.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash() err = %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=2,p=1$") {
+		t.Errorf("Hash() = %q, want PHC string with parameters", hash)
+	}
+
+	// Salts are random, so the same password never hashes the same way twice
+	other, _ := hasher.Hash("password")
+	if hash == other {
+		t.Errorf("Hash() returned the same hash twice")
+	}
+}
+
+// Unit tests to check bcrypt refuses passwords it would otherwise truncate
+func TestBcryptHasherLongPassword(t *testing.T) {
+	_, err := BcryptHasher{Cost: bcrypt.MinCost}.Hash(strings.Repeat("a", 73))
+	if err == nil {
+		t.Errorf("Hash() err = nil, want error for password over 72 bytes")
+	}
+}
+
+// Unit tests to check the password policy
+func TestPasswordPolicyValidate(t *testing.T) {
+
+	// Policy used for every case
+	policy := NewPasswordPolicy()
+	policy.Breached = map[string]struct{}{"password123": {}}
+
+	// Create a struct for test data
+	tests := []struct {
+		name     string
+		password string
+		wantErr  bool
+	}{
+		// Test 1
+		{
+			name:     "Long enough",
+			password: "correct horse",
+		},
+
+		// Test 2
+		{
+			name:     "Too short",
+			password: "short",
+			wantErr:  true,
+		},
+
+		// Test 3
+		{
+			name:     "Length counts characters not bytes",
+			password: "ñandú€€€",
+		},
+
+		// Test 4
+		{
+			name:     "Too long",
+			password: strings.Repeat("a", 129),
+			wantErr:  true,
+		},
+
+		// Test 5
+		{
+			name:     "Breached",
+			password: "password123",
+			wantErr:  true,
+		},
+	}
+
+	// Loop through test cases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Unit tests to check breached password lists are loaded one password per line
+func TestLoadBreachedPasswords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	err := os.WriteFile(path, []byte("123456\r\npassword\n\nqwerty\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	breached, err := LoadBreachedPasswords(path)
+	if err != nil {
+		t.Fatalf("LoadBreachedPasswords() err = %v", err)
+	}
+	if len(breached) != 3 {
+		t.Errorf("LoadBreachedPasswords() loaded %d passwords, want 3", len(breached))
+	}
+	for _, password := range []string{"123456", "password", "qwerty"} {
+		if _, ok := breached[password]; !ok {
+			t.Errorf("LoadBreachedPasswords() missing %q", password)
+		}
+	}
+}
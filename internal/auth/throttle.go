@@ -0,0 +1,46 @@
+package auth
+
+import "time"
+
+// ThrottlePolicy describes how failed login attempts slow down further attempts: a few
+// free failures, then exponential backoff, then a lockout once the threshold is reached
+type ThrottlePolicy struct {
+	// Failures allowed before any delay is applied
+	FreeAttempts int32
+	// Delay after the first failure past FreeAttempts, doubling with each further failure
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Failures that lock the key out, and for how long
+	LockoutAfter int32
+	LockoutFor   time.Duration
+	// Failure counts are forgotten after this long without a failure. It must be at least
+	// LockoutFor so a lockout can't be cut short.
+	ResetAfter time.Duration
+}
+
+// Function to get how long after the latest failure the next attempt is blocked
+func (p ThrottlePolicy) Delay(failures int32) time.Duration {
+	if failures >= p.LockoutAfter {
+		return p.LockoutFor
+	}
+	if failures <= p.FreeAttempts {
+		return 0
+	}
+	delay := p.BaseDelay
+	for range failures - p.FreeAttempts - 1 {
+		delay *= 2
+		if delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	return min(delay, p.MaxDelay)
+}
+
+// Function to get how long until another attempt is allowed, given the failure count and
+// time of the latest failure. Zero means an attempt is allowed now.
+func (p ThrottlePolicy) RetryAfter(failures int32, lastFailureAt, now time.Time) time.Duration {
+	if now.Sub(lastFailureAt) >= p.ResetAfter {
+		return 0
+	}
+	return max(lastFailureAt.Add(p.Delay(failures)).Sub(now), 0)
+}
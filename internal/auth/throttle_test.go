@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+// Unit tests to check login throttling backs off exponentially and then locks out
+func TestThrottlePolicyDelay(t *testing.T) {
+
+	// Policy used for every case
+	policy := ThrottlePolicy{
+		FreeAttempts: 3,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+		LockoutAfter: 10,
+		LockoutFor:   15 * time.Minute,
+		ResetAfter:   15 * time.Minute,
+	}
+
+	// Create a struct for test data
+	tests := []struct {
+		name     string
+		failures int32
+		want     time.Duration
+	}{
+		// Test 1
+		{
+			name:     "No failures",
+			failures: 0,
+			want:     0,
+		},
+
+		// Test 2
+		{
+			name:     "Within free attempts",
+			failures: 3,
+			want:     0,
+		},
+
+		// Test 3
+		{
+			name:     "First delayed failure",
+			failures: 4,
+			want:     time.Second,
+		},
+
+		// Test 4
+		{
+			name:     "Delay doubles",
+			failures: 6,
+			want:     4 * time.Second,
+		},
+
+		// Test 5
+		{
+			name:     "Delay capped",
+			failures: 9,
+			want:     32 * time.Second,
+		},
+
+		// Test 6
+		{
+			name:     "Locked out at threshold",
+			failures: 10,
+			want:     15 * time.Minute,
+		},
+	}
+
+	// Loop through test cases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Delay(tt.failures); got != tt.want {
+				t.Errorf("Delay(%d) = %v, want %v", tt.failures, got, tt.want)
+			}
+		})
+	}
+
+	// A small cap applies before the lockout threshold
+	capped := policy
+	capped.MaxDelay = 5 * time.Second
+	if got := capped.Delay(9); got != 5*time.Second {
+		t.Errorf("Delay(9) with cap = %v, want %v", got, 5*time.Second)
+	}
+}
+
+// Unit test to check the time until the next attempt counts down and resets
+func TestThrottlePolicyRetryAfter(t *testing.T) {
+	policy := ThrottlePolicy{
+		FreeAttempts: 0,
+		BaseDelay:    10 * time.Second,
+		MaxDelay:     time.Minute,
+		LockoutAfter: 5,
+		LockoutFor:   time.Hour,
+		ResetAfter:   time.Hour,
+	}
+	last := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	if got := policy.RetryAfter(1, last, last.Add(4*time.Second)); got != 6*time.Second {
+		t.Errorf("RetryAfter() = %v, want %v", got, 6*time.Second)
+	}
+	if got := policy.RetryAfter(1, last, last.Add(time.Minute)); got != 0 {
+		t.Errorf("RetryAfter() after delay = %v, want 0", got)
+	}
+	if got := policy.RetryAfter(5, last, last.Add(59*time.Minute)); got != time.Minute {
+		t.Errorf("RetryAfter() during lockout = %v, want %v", got, time.Minute)
+	}
+	if got := policy.RetryAfter(5, last, last.Add(time.Hour)); got != 0 {
+		t.Errorf("RetryAfter() after reset = %v, want 0", got)
+	}
+}
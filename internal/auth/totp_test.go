@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Unit tests to check HOTP/TOTP values against the RFC 6238 SHA1 test vectors
+func TestHOTPVectors(t *testing.T) {
+
+	// Secret used by the RFC test vectors
+	key := []byte("12345678901234567890")
+
+	// Create a struct for test data
+	tests := []struct {
+		name string
+		unix int64
+		want string
+	}{
+		// Test 1
+		{
+			name: "59 seconds",
+			unix: 59,
+			want: "94287082",
+		},
+
+		// Test 2
+		{
+			name: "1111111109 seconds",
+			unix: 1111111109,
+			want: "07081804",
+		},
+
+		// Test 3
+		{
+			name: "1111111111 seconds",
+			unix: 1111111111,
+			want: "14050471",
+		},
+
+		// Test 4
+		{
+			name: "1234567890 seconds",
+			unix: 1234567890,
+			want: "89005924",
+		},
+
+		// Test 5
+		{
+			name: "2000000000 seconds",
+			unix: 2000000000,
+			want: "69279037",
+		},
+
+		// Test 6
+		{
+			name: "20000000000 seconds",
+			unix: 20000000000,
+			want: "65353130",
+		},
+	}
+
+	// Loop through test cases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := uint64(TOTPStep(time.Unix(tt.unix, 0)))
+			if got := hotp(key, counter, 8); got != tt.want {
+				t.Errorf("hotp() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Unit tests to check TOTP codes are validated within the allowed clock skew
+func TestValidateTOTP(t *testing.T) {
+
+	// Use the RFC secret so codes are known
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(59, 0)
+
+	// Create a struct for test data
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		wantStep int64
+		wantErr  bool
+	}{
+		// Test 1
+		{
+			name:     "Current code",
+			secret:   secret,
+			code:     "287082",
+			wantStep: 1,
+			wantErr:  false,
+		},
+
+		// Test 2
+		{
+			name:     "Previous period within skew",
+			secret:   secret,
+			code:     "755224",
+			wantStep: 0,
+			wantErr:  false,
+		},
+
+		// Test 3
+		{
+			name:     "Lowercase secret with padding",
+			secret:   strings.ToLower(secret),
+			code:     "287082",
+			wantStep: 1,
+			wantErr:  false,
+		},
+
+		// Test 4
+		{
+			name:    "Wrong code",
+			secret:  secret,
+			code:    "123456",
+			wantErr: true,
+		},
+
+		// Test 5
+		{
+			name:    "Wrong length",
+			secret:  secret,
+			code:    "28708",
+			wantErr: true,
+		},
+
+		// Test 6
+		{
+			name:    "Invalid secret",
+			secret:  "not base32!",
+			code:    "287082",
+			wantErr: true,
+		},
+	}
+
+	// Loop through test cases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, err := ValidateTOTP(tt.secret, tt.code, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateTOTP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && gotStep != tt.wantStep {
+				t.Errorf("ValidateTOTP() step = %v, want %v", gotStep, tt.wantStep)
+			}
+		})
+	}
+}
+
+// Unit test to check generated secrets produce codes that validate
+func TestTOTPRoundTrip(t *testing.T) {
+	secret, err := MakeTOTPSecret()
+	if err != nil {
+		t.Fatalf("MakeTOTPSecret() error = %v", err)
+	}
+	now := time.Now()
+	code, err := TOTPCode(secret, now)
+	if err != nil {
+		t.Fatalf("TOTPCode() error = %v", err)
+	}
+	step, err := ValidateTOTP(secret, code, now)
+	if err != nil {
+		t.Fatalf("ValidateTOTP() error = %v", err)
+	}
+	if step != TOTPStep(now) {
+		t.Errorf("ValidateTOTP() step = %v, want %v", step, TOTPStep(now))
+	}
+	if !strings.HasPrefix(TOTPURI("Chirpy", "user@example.com", secret), "otpauth://totp/Chirpy:user@example.com?") {
+		t.Errorf("TOTPURI() has unexpected label")
+	}
+}
+
+// Unit test to check recovery codes are unique and hash independent of formatting
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := MakeRecoveryCodes()
+	if err != nil {
+		t.Fatalf("MakeRecoveryCodes() error = %v", err)
+	}
+	if len(codes) != RecoveryCodeCount {
+		t.Fatalf("MakeRecoveryCodes() returned %d codes, want %d", len(codes), RecoveryCodeCount)
+	}
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if seen[code] {
+			t.Errorf("duplicate recovery code %s", code)
+		}
+		seen[code] = true
+	}
+	formatted := codes[0]
+	if HashRecoveryCode(formatted) != HashRecoveryCode(strings.ToUpper(strings.ReplaceAll(formatted, "-", ""))) {
+		t.Errorf("HashRecoveryCode() depends on formatting")
+	}
+}
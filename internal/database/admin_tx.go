@@ -0,0 +1,177 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// User roles, from least to most privileged
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Actions recorded in the audit log
+const (
+	AuditActionUserSuspend       = "user.suspend"
+	AuditActionUserUnsuspend     = "user.unsuspend"
+	AuditActionUserPasswordReset = "user.force_password_reset"
+	AuditActionUserRoleChange    = "user.role_change"
+	AuditActionChirpDelete       = "chirp.delete"
+	AuditActionDatabaseReset     = "database.reset"
+	AuditActionJobRetry          = "job.retry"
+	AuditTargetUser              = "user"
+	AuditTargetChirp             = "chirp"
+	AuditTargetDatabase          = "database"
+	AuditTargetJob               = "job"
+)
+
+// Struct for who performed an admin action and why
+type AuditActor struct {
+	ID     uuid.UUID
+	Reason string
+}
+
+// Function to write an audit log entry inside a transaction. Details are stored as a JSON
+// object alongside the reason given by the actor.
+func audit(ctx context.Context, q *Queries, actor AuditActor, action, targetType string, targetID uuid.UUID, details map[string]any) error {
+	if details == nil {
+		details = map[string]any{}
+	}
+	if actor.Reason != "" {
+		details["reason"] = actor.Reason
+	}
+	dat, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	return q.CreateAuditLogEntry(ctx, CreateAuditLogEntryParams{
+		ActorID:    uuid.NullUUID{UUID: actor.ID, Valid: actor.ID != uuid.Nil},
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   uuid.NullUUID{UUID: targetID, Valid: targetID != uuid.Nil},
+		Details:    dat,
+	})
+}
+
+// Function to log a user out everywhere inside a transaction. Access tokens issued
+// before validAfter stop being accepted.
+func revokeUserSessions(ctx context.Context, q *Queries, userID uuid.UUID, validAfter time.Time) error {
+	err := q.RevokeAllRefreshTokens(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return q.SetTokensValidAfter(ctx, SetTokensValidAfterParams{
+		ID:               userID,
+		TokensValidAfter: sql.NullTime{Time: validAfter, Valid: true},
+	})
+}
+
+// Method to suspend a user, logging them out everywhere. Returns sql.ErrNoRows if the user
+// doesn't exist.
+func (s *Store) SuspendUserTx(ctx context.Context, actor AuditActor, userID uuid.UUID, validAfter time.Time) (User, error) {
+	var user User
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+		user, err = q.SetUserSuspended(ctx, SetUserSuspendedParams{
+			Suspended: true,
+			ID:        userID,
+		})
+		if err != nil {
+			return err
+		}
+		err = revokeUserSessions(ctx, q, userID, validAfter)
+		if err != nil {
+			return err
+		}
+		return audit(ctx, q, actor, AuditActionUserSuspend, AuditTargetUser, userID, nil)
+	})
+	return user, err
+}
+
+// Method to lift a user's suspension. Returns sql.ErrNoRows if the user doesn't exist.
+func (s *Store) UnsuspendUserTx(ctx context.Context, actor AuditActor, userID uuid.UUID) (User, error) {
+	var user User
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+		user, err = q.SetUserSuspended(ctx, SetUserSuspendedParams{
+			Suspended: false,
+			ID:        userID,
+		})
+		if err != nil {
+			return err
+		}
+		return audit(ctx, q, actor, AuditActionUserUnsuspend, AuditTargetUser, userID, nil)
+	})
+	return user, err
+}
+
+// Method to require a user to reset their password before logging in again, logging them
+// out everywhere. Returns sql.ErrNoRows if the user doesn't exist.
+func (s *Store) ForcePasswordResetTx(ctx context.Context, actor AuditActor, userID uuid.UUID, validAfter time.Time) (User, error) {
+	var user User
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+		user, err = q.SetPasswordResetRequired(ctx, userID)
+		if err != nil {
+			return err
+		}
+		err = revokeUserSessions(ctx, q, userID, validAfter)
+		if err != nil {
+			return err
+		}
+		return audit(ctx, q, actor, AuditActionUserPasswordReset, AuditTargetUser, userID, nil)
+	})
+	return user, err
+}
+
+// Method to change a user's role, recording the old and new roles. Returns sql.ErrNoRows if
+// the user doesn't exist.
+func (s *Store) SetUserRoleTx(ctx context.Context, actor AuditActor, userID uuid.UUID, role string) (User, error) {
+	var user User
+	err := s.execTx(ctx, func(q *Queries) error {
+		current, err := q.GetUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+		user, err = q.SetUserRole(ctx, SetUserRoleParams{
+			ID:   userID,
+			Role: role,
+		})
+		if err != nil {
+			return err
+		}
+		return audit(ctx, q, actor, AuditActionUserRoleChange, AuditTargetUser, userID, map[string]any{
+			"from": current.Role,
+			"to":   role,
+		})
+	})
+	return user, err
+}
+
+// Method to delete any user's chirp as a moderator, recording its author. Returns
+// sql.ErrNoRows if the chirp doesn't exist or has been deleted.
+func (s *Store) AdminDeleteChirpTx(ctx context.Context, actor AuditActor, chirpID uuid.UUID) error {
+	return s.execTx(ctx, func(q *Queries) error {
+		chirp, err := removeChirp(ctx, q, chirpID)
+		if err != nil {
+			return err
+		}
+		return audit(ctx, q, actor, AuditActionChirpDelete, AuditTargetChirp, chirpID, map[string]any{
+			"author_id": chirp.UserID,
+		})
+	})
+}
+
+// Method to record that the database was reset. The reset deletes every user, so the
+// entry has no actor.
+func (s *Store) AuditDatabaseReset(ctx context.Context, actorEmail string) error {
+	return audit(ctx, s.Queries, AuditActor{}, AuditActionDatabaseReset, AuditTargetDatabase, uuid.Nil, map[string]any{
+		"actor_email": actorEmail,
+	})
+}
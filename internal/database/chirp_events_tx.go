@@ -0,0 +1,33 @@
+package database
+
+import (
+	"context"
+	"strconv"
+)
+
+// ChirpEventsChannel is the Postgres NOTIFY channel carrying new chirp event IDs
+const ChirpEventsChannel = "chirp_events"
+
+// Chirp event types stored in chirp_events.type
+const (
+	ChirpEventCreated = "chirp_created"
+	ChirpEventDeleted = "chirp_deleted"
+)
+
+// Function to record a chirp event, notify listeners of its ID and queue webhook deliveries
+// to the author's endpoints. Both only go out once the surrounding transaction commits.
+func publishChirpEvent(ctx context.Context, q *Queries, eventType string, chirp Chirp) error {
+	id, err := q.CreateChirpEvent(ctx, CreateChirpEventParams{
+		Type:    eventType,
+		ChirpID: chirp.ID,
+		UserID:  chirp.UserID,
+	})
+	if err != nil {
+		return err
+	}
+	err = enqueueChirpWebhook(ctx, q, eventType, chirp)
+	if err != nil {
+		return err
+	}
+	return q.NotifyChirpEvent(ctx, strconv.FormatInt(id, 10))
+}
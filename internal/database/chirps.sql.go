@@ -7,29 +7,48 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const chirpHasReplies = `-- name: ChirpHasReplies :one
+SELECT EXISTS (
+    SELECT 1 FROM chirps
+    WHERE in_reply_to = $1::uuid
+)::boolean AS has_replies
+`
+
+func (q *Queries) ChirpHasReplies(ctx context.Context, chirpID uuid.UUID) (bool, error) {
+	row := q.db.QueryRowContext(ctx, chirpHasReplies, chirpID)
+	var has_replies bool
+	err := row.Scan(&has_replies)
+	return has_replies, err
+}
+
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, reply_count, deleted_at, like_count, rechirp_count, edited_at
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body      string
+	UserID    uuid.UUID
+	InReplyTo uuid.NullUUID
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID, arg.InReplyTo)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -37,10 +56,26 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.InReplyTo,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.RechirpCount,
+		&i.EditedAt,
 	)
 	return i, err
 }
 
+const decrementReplyCount = `-- name: DecrementReplyCount :exec
+UPDATE chirps SET reply_count = GREATEST(reply_count - 1, 0)
+WHERE id = $1
+`
+
+func (q *Queries) DecrementReplyCount(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, decrementReplyCount, id)
+	return err
+}
+
 const deleteChirp = `-- name: DeleteChirp :exec
 DELETE FROM chirps
 WHERE id = $1
@@ -52,7 +87,7 @@ func (q *Queries) DeleteChirp(ctx context.Context, id uuid.UUID) error {
 }
 
 const getChirp = `-- name: GetChirp :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, reply_count, deleted_at, like_count, rechirp_count, edited_at FROM chirps
 WHERE id = $1
 `
 
@@ -65,17 +100,402 @@ func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.InReplyTo,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.RechirpCount,
+		&i.EditedAt,
 	)
 	return i, err
 }
 
-const getChirps = `-- name: GetChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-ORDER BY created_at ASC
+const incrementReplyCount = `-- name: IncrementReplyCount :one
+UPDATE chirps SET reply_count = reply_count + 1
+WHERE id = $1
+AND deleted_at IS NULL
+RETURNING user_id
+`
+
+func (q *Queries) IncrementReplyCount(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, incrementReplyCount, id)
+	var user_id uuid.UUID
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const listAuthorFeedAsc = `-- name: ListAuthorFeedAsc :many
+WITH feed AS (
+    SELECT c.id AS chirp_id, c.created_at AS activity_at, NULL::uuid AS rechirped_by
+    FROM chirps c
+    WHERE c.user_id = $4
+    UNION ALL
+    SELECT r.chirp_id, r.created_at, r.user_id
+    FROM rechirps r
+    WHERE r.user_id = $4
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirps.reply_count, chirps.deleted_at, chirps.like_count, chirps.rechirp_count, chirps.edited_at, feed.activity_at::timestamp AS activity_at, feed.rechirped_by::uuid AS rechirped_by FROM feed
+JOIN chirps ON chirps.id = feed.chirp_id
+WHERE chirps.deleted_at IS NULL
+AND ($1::timestamp IS NULL
+    OR (feed.activity_at, chirps.id) > ($1::timestamp, $2::uuid))
+ORDER BY feed.activity_at ASC, chirps.id ASC
+LIMIT $3
+`
+
+type ListAuthorFeedAscParams struct {
+	AfterCreatedAt sql.NullTime
+	AfterID        uuid.NullUUID
+	PageSize       int32
+	AuthorID       uuid.UUID
+}
+
+type ListAuthorFeedAscRow struct {
+	Chirp       Chirp
+	ActivityAt  time.Time
+	RechirpedBy uuid.UUID
+}
+
+func (q *Queries) ListAuthorFeedAsc(ctx context.Context, arg ListAuthorFeedAscParams) ([]ListAuthorFeedAscRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAuthorFeedAsc,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.PageSize,
+		arg.AuthorID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAuthorFeedAscRow
+	for rows.Next() {
+		var i ListAuthorFeedAscRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.InReplyTo,
+			&i.Chirp.ReplyCount,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.LikeCount,
+			&i.Chirp.RechirpCount,
+			&i.Chirp.EditedAt,
+			&i.ActivityAt,
+			&i.RechirpedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuthorFeedDesc = `-- name: ListAuthorFeedDesc :many
+WITH feed AS (
+    SELECT c.id AS chirp_id, c.created_at AS activity_at, NULL::uuid AS rechirped_by
+    FROM chirps c
+    WHERE c.user_id = $4
+    UNION ALL
+    SELECT r.chirp_id, r.created_at, r.user_id
+    FROM rechirps r
+    WHERE r.user_id = $4
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirps.reply_count, chirps.deleted_at, chirps.like_count, chirps.rechirp_count, chirps.edited_at, feed.activity_at::timestamp AS activity_at, feed.rechirped_by::uuid AS rechirped_by FROM feed
+JOIN chirps ON chirps.id = feed.chirp_id
+WHERE chirps.deleted_at IS NULL
+AND ($1::timestamp IS NULL
+    OR (feed.activity_at, chirps.id) < ($1::timestamp, $2::uuid))
+ORDER BY feed.activity_at DESC, chirps.id DESC
+LIMIT $3
+`
+
+type ListAuthorFeedDescParams struct {
+	AfterCreatedAt sql.NullTime
+	AfterID        uuid.NullUUID
+	PageSize       int32
+	AuthorID       uuid.UUID
+}
+
+type ListAuthorFeedDescRow struct {
+	Chirp       Chirp
+	ActivityAt  time.Time
+	RechirpedBy uuid.UUID
+}
+
+func (q *Queries) ListAuthorFeedDesc(ctx context.Context, arg ListAuthorFeedDescParams) ([]ListAuthorFeedDescRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAuthorFeedDesc,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.PageSize,
+		arg.AuthorID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAuthorFeedDescRow
+	for rows.Next() {
+		var i ListAuthorFeedDescRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.InReplyTo,
+			&i.Chirp.ReplyCount,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.LikeCount,
+			&i.Chirp.RechirpCount,
+			&i.Chirp.EditedAt,
+			&i.ActivityAt,
+			&i.RechirpedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpAncestors = `-- name: ListChirpAncestors :many
+WITH RECURSIVE ancestors(id, in_reply_to, depth) AS (
+    SELECT c.id, c.in_reply_to, 1
+    FROM chirps c
+    WHERE c.id = (SELECT p.in_reply_to FROM chirps p WHERE p.id = $1)
+    UNION ALL
+    SELECT c.id, c.in_reply_to, a.depth + 1
+    FROM chirps c
+    JOIN ancestors a ON c.id = a.in_reply_to
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirps.reply_count, chirps.deleted_at, chirps.like_count, chirps.rechirp_count, chirps.edited_at FROM chirps
+JOIN ancestors ON ancestors.id = chirps.id
+ORDER BY ancestors.depth DESC
+`
+
+func (q *Queries) ListChirpAncestors(ctx context.Context, chirpID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpAncestors, chirpID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpCount,
+			&i.EditedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpDescendants = `-- name: ListChirpDescendants :many
+WITH RECURSIVE descendants(id, depth, sort_key) AS (
+    SELECT c.id, 1, to_char(c.created_at, 'YYYYMMDDHH24MISSUS') || c.id::text
+    FROM chirps c
+    WHERE c.in_reply_to = $3::uuid
+    UNION ALL
+    SELECT c.id, d.depth + 1, d.sort_key || '/' || to_char(c.created_at, 'YYYYMMDDHH24MISSUS') || c.id::text
+    FROM chirps c
+    JOIN descendants d ON c.in_reply_to = d.id
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirps.reply_count, chirps.deleted_at, chirps.like_count, chirps.rechirp_count, chirps.edited_at, descendants.depth::integer AS depth, descendants.sort_key::text AS sort_key FROM chirps
+JOIN descendants ON descendants.id = chirps.id
+WHERE $1::text IS NULL
+OR descendants.sort_key > $1::text
+ORDER BY descendants.sort_key
+LIMIT $2
+`
+
+type ListChirpDescendantsParams struct {
+	AfterSortKey sql.NullString
+	PageSize     int32
+	ChirpID      uuid.UUID
+}
+
+type ListChirpDescendantsRow struct {
+	Chirp   Chirp
+	Depth   int32
+	SortKey string
+}
+
+func (q *Queries) ListChirpDescendants(ctx context.Context, arg ListChirpDescendantsParams) ([]ListChirpDescendantsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpDescendants, arg.AfterSortKey, arg.PageSize, arg.ChirpID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListChirpDescendantsRow
+	for rows.Next() {
+		var i ListChirpDescendantsRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.InReplyTo,
+			&i.Chirp.ReplyCount,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.LikeCount,
+			&i.Chirp.RechirpCount,
+			&i.Chirp.EditedAt,
+			&i.Depth,
+			&i.SortKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsAsc = `-- name: ListChirpsAsc :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, reply_count, deleted_at, like_count, rechirp_count, edited_at FROM chirps
+WHERE deleted_at IS NULL
+AND ($1::timestamp IS NULL
+    OR (created_at, id) > ($1::timestamp, $2::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT $3
+`
+
+type ListChirpsAscParams struct {
+	AfterCreatedAt sql.NullTime
+	AfterID        uuid.NullUUID
+	PageSize       int32
+}
+
+func (q *Queries) ListChirpsAsc(ctx context.Context, arg ListChirpsAscParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsAsc, arg.AfterCreatedAt, arg.AfterID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpCount,
+			&i.EditedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsByIDs = `-- name: ListChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, reply_count, deleted_at, like_count, rechirp_count, edited_at FROM chirps
+WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) ListChirpsByIDs(ctx context.Context, ids []uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpCount,
+			&i.EditedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsDesc = `-- name: ListChirpsDesc :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, reply_count, deleted_at, like_count, rechirp_count, edited_at FROM chirps
+WHERE deleted_at IS NULL
+AND ($1::timestamp IS NULL
+    OR (created_at, id) < ($1::timestamp, $2::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $3
 `
 
-func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirps)
+type ListChirpsDescParams struct {
+	AfterCreatedAt sql.NullTime
+	AfterID        uuid.NullUUID
+	PageSize       int32
+}
+
+func (q *Queries) ListChirpsDesc(ctx context.Context, arg ListChirpsDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsDesc, arg.AfterCreatedAt, arg.AfterID, arg.PageSize)
 	if err != nil {
 		return nil, err
 	}
@@ -89,6 +509,12 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.InReplyTo,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpCount,
+			&i.EditedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -102,3 +528,100 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 	}
 	return items, nil
 }
+
+const lockChirp = `-- name: LockChirp :one
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, reply_count, deleted_at, like_count, rechirp_count, edited_at FROM chirps
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, lockChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.RechirpCount,
+		&i.EditedAt,
+	)
+	return i, err
+}
+
+const recomputeChirpCounters = `-- name: RecomputeChirpCounters :execrows
+UPDATE chirps SET like_count = counts.like_count,
+rechirp_count = counts.rechirp_count,
+reply_count = counts.reply_count
+FROM (
+    SELECT c.id,
+        COALESCE(l.n, 0)::integer AS like_count,
+        COALESCE(rc.n, 0)::integer AS rechirp_count,
+        COALESCE(rp.n, 0)::integer AS reply_count
+    FROM chirps c
+    LEFT JOIN (SELECT chirp_id, COUNT(*) AS n FROM likes GROUP BY chirp_id) l ON l.chirp_id = c.id
+    LEFT JOIN (SELECT chirp_id, COUNT(*) AS n FROM rechirps GROUP BY chirp_id) rc ON rc.chirp_id = c.id
+    LEFT JOIN (
+        SELECT in_reply_to, COUNT(*) AS n FROM chirps
+        WHERE in_reply_to IS NOT NULL
+        AND deleted_at IS NULL
+        GROUP BY in_reply_to
+    ) rp ON rp.in_reply_to = c.id
+) counts
+WHERE chirps.id = counts.id
+AND (chirps.like_count, chirps.rechirp_count, chirps.reply_count)
+    IS DISTINCT FROM (counts.like_count, counts.rechirp_count, counts.reply_count)
+`
+
+func (q *Queries) RecomputeChirpCounters(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, recomputeChirpCounters)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const tombstoneChirp = `-- name: TombstoneChirp :exec
+UPDATE chirps SET body = '', deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) TombstoneChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, tombstoneChirp, id)
+	return err
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+UPDATE chirps SET body = $2, edited_at = NOW(), updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, reply_count, deleted_at, like_count, rechirp_count, edited_at
+`
+
+type UpdateChirpBodyParams struct {
+	ID   uuid.UUID
+	Body string
+}
+
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody, arg.ID, arg.Body)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.RechirpCount,
+		&i.EditedAt,
+	)
+	return i, err
+}
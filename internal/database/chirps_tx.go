@@ -0,0 +1,186 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+// Method to create a chirp with its hashtags and mentions and, for replies, bump the parent's
+// reply count atomically. Publishes a chirp_created event on commit. Returns sql.ErrNoRows if
+// the parent doesn't exist or has been deleted.
+func (s *Store) CreateChirpTx(ctx context.Context, arg CreateChirpParams, ents ChirpEntities) (Chirp, error) {
+	var chirp Chirp
+	err := s.execTx(ctx, func(q *Queries) error {
+
+		// Increment parent first so its row lock serializes against a concurrent delete
+		// (a missing or deleted parent returns sql.ErrNoRows)
+		var parentAuthorID uuid.UUID
+		if arg.InReplyTo.Valid {
+			var err error
+			parentAuthorID, err = q.IncrementReplyCount(ctx, arg.InReplyTo.UUID)
+			if err != nil {
+				return err
+			}
+		}
+
+		var err error
+		chirp, err = q.CreateChirp(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		// Notify the parent's author of the reply
+		if arg.InReplyTo.Valid {
+			err = notify(ctx, q, CreateNotificationParams{
+				UserID:  parentAuthorID,
+				Type:    NotificationTypeReply,
+				ActorID: uuid.NullUUID{UUID: chirp.UserID, Valid: true},
+				ChirpID: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		err = setChirpTags(ctx, q, chirp.ID, ents.Tags)
+		if err != nil {
+			return err
+		}
+		err = setChirpMentions(ctx, q, chirp, ents.Mentions, nil)
+		if err != nil {
+			return err
+		}
+		return publishChirpEvent(ctx, q, ChirpEventCreated, chirp)
+	})
+	return chirp, err
+}
+
+// Method to delete a chirp. Chirps with replies are replaced with a tombstone so
+// their threads stay intact; chirps without replies are removed outright. Either way a
+// chirp_deleted event is published on commit.
+func (s *Store) DeleteChirpTx(ctx context.Context, chirpID uuid.UUID) error {
+	return s.execTx(ctx, func(q *Queries) error {
+		_, err := removeChirp(ctx, q, chirpID)
+		return err
+	})
+}
+
+// Function to delete a chirp inside a transaction, returning the chirp as it was before
+// deletion. Returns sql.ErrNoRows if the chirp doesn't exist or has been deleted.
+func removeChirp(ctx context.Context, q *Queries, chirpID uuid.UUID) (Chirp, error) {
+
+	// Lock chirp so replies can't be attached while deciding how to delete it
+	chirp, err := q.LockChirp(ctx, chirpID)
+	if err != nil {
+		return Chirp{}, err
+	}
+	if chirp.DeletedAt.Valid {
+		return Chirp{}, sql.ErrNoRows
+	}
+
+	// A deleted reply no longer counts towards its parent
+	if chirp.InReplyTo.Valid {
+		err = q.DecrementReplyCount(ctx, chirp.InReplyTo.UUID)
+		if err != nil {
+			return Chirp{}, err
+		}
+	}
+
+	hasReplies, err := q.ChirpHasReplies(ctx, chirpID)
+	if err != nil {
+		return Chirp{}, err
+	}
+	if hasReplies {
+		// Tombstones keep no trace of their content, including past revisions, tags and mentions
+		err = q.DeleteChirpRevisions(ctx, chirpID)
+		if err != nil {
+			return Chirp{}, err
+		}
+		err = q.DeleteChirpTags(ctx, chirpID)
+		if err != nil {
+			return Chirp{}, err
+		}
+		err = q.DeleteChirpMentions(ctx, chirpID)
+		if err != nil {
+			return Chirp{}, err
+		}
+		err = q.TombstoneChirp(ctx, chirpID)
+	} else {
+		err = q.DeleteChirp(ctx, chirpID)
+	}
+	if err != nil {
+		return Chirp{}, err
+	}
+	return chirp, publishChirpEvent(ctx, q, ChirpEventDeleted, chirp)
+}
+
+// Method to replace a chirp's body, hashtags and mentions, saving the previous body as a
+// revision. Returns sql.ErrNoRows if the chirp doesn't exist or has been deleted.
+func (s *Store) EditChirpTx(ctx context.Context, chirpID uuid.UUID, body string, ents ChirpEntities) (Chirp, error) {
+	var chirp Chirp
+	err := s.execTx(ctx, func(q *Queries) error {
+
+		// Lock chirp so concurrent edits each record the body they replaced
+		current, err := q.LockChirp(ctx, chirpID)
+		if err != nil {
+			return err
+		}
+		if current.DeletedAt.Valid {
+			return sql.ErrNoRows
+		}
+
+		// The replaced body was written when the chirp was created or last edited
+		writtenAt := current.CreatedAt
+		if current.EditedAt.Valid {
+			writtenAt = current.EditedAt.Time
+		}
+		err = q.CreateChirpRevision(ctx, CreateChirpRevisionParams{
+			ChirpID:   chirpID,
+			Body:      current.Body,
+			CreatedAt: writtenAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		chirp, err = q.UpdateChirpBody(ctx, UpdateChirpBodyParams{
+			ID:   chirpID,
+			Body: body,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Replace hashtags with those in the new body. Tags the chirp keeps aren't touched,
+		// so they keep counting toward trending from when they were first used.
+		err = q.DeleteChirpTagsExcept(ctx, DeleteChirpTagsExceptParams{
+			ChirpID: chirpID,
+			Keep:    ents.Tags,
+		})
+		if err != nil {
+			return err
+		}
+		err = setChirpTags(ctx, q, chirpID, ents.Tags)
+		if err != nil {
+			return err
+		}
+
+		// Replace mentions, only notifying users who weren't mentioned before the edit
+		previousIDs, err := q.ListChirpMentionedUserIDs(ctx, chirpID)
+		if err != nil {
+			return err
+		}
+		alreadyNotified := map[uuid.UUID]struct{}{}
+		for _, id := range previousIDs {
+			alreadyNotified[id] = struct{}{}
+		}
+		err = q.DeleteChirpMentions(ctx, chirpID)
+		if err != nil {
+			return err
+		}
+		return setChirpMentions(ctx, q, chirp, ents.Mentions, alreadyNotified)
+	})
+	return chirp, err
+}
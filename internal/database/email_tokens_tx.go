@@ -0,0 +1,108 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Purposes of single-use tokens sent by email
+const (
+	EmailTokenPurposeVerify        = "verify_email"
+	EmailTokenPurposePasswordReset = "password_reset"
+)
+
+// ErrEmailChanged is returned when a verification token was sent to an address the user
+// no longer has
+var ErrEmailChanged = errors.New("email address has changed since token was sent")
+
+// Method to issue an email token, replacing any earlier tokens the user has for the same
+// purpose so only the latest email's link works
+func (s *Store) ReplaceEmailTokenTx(ctx context.Context, arg CreateEmailTokenParams) error {
+	return s.execTx(ctx, func(q *Queries) error {
+		err := q.DeleteEmailTokens(ctx, DeleteEmailTokensParams{
+			UserID:  arg.UserID,
+			Purpose: arg.Purpose,
+		})
+		if err != nil {
+			return err
+		}
+		return q.CreateEmailToken(ctx, arg)
+	})
+}
+
+// Method to use an email verification token, marking the address it was sent to as
+// verified. Returns sql.ErrNoRows if the token is unknown, used or expired.
+func (s *Store) VerifyEmailTx(ctx context.Context, tokenHash string) (User, error) {
+	var user User
+	err := s.execTx(ctx, func(q *Queries) error {
+		token, err := q.UseEmailToken(ctx, UseEmailTokenParams{
+			TokenHash: tokenHash,
+			Purpose:   EmailTokenPurposeVerify,
+		})
+		if err != nil {
+			return err
+		}
+		n, err := q.MarkEmailVerified(ctx, MarkEmailVerifiedParams{
+			ID:    token.UserID,
+			Email: token.Email,
+		})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrEmailChanged
+		}
+		user, err = q.GetUser(ctx, token.UserID)
+		return err
+	})
+	return user, err
+}
+
+// Method to use a password reset token to set a new password. Every session is logged out
+// and access tokens issued before validAfter stop being accepted. Returns sql.ErrNoRows if
+// the token is unknown, used or expired.
+func (s *Store) ResetPasswordTx(ctx context.Context, tokenHash, hashedPassword string, validAfter time.Time) (User, error) {
+	var user User
+	err := s.execTx(ctx, func(q *Queries) error {
+		token, err := q.UseEmailToken(ctx, UseEmailTokenParams{
+			TokenHash: tokenHash,
+			Purpose:   EmailTokenPurposePasswordReset,
+		})
+		if err != nil {
+			return err
+		}
+		err = q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			ID:             token.UserID,
+			HashedPassword: hashedPassword,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Clicking the link proves the user still reads the address it was sent to
+		_, err = q.MarkEmailVerified(ctx, MarkEmailVerifiedParams{
+			ID:    token.UserID,
+			Email: token.Email,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.RevokeAllRefreshTokens(ctx, token.UserID)
+		if err != nil {
+			return err
+		}
+		err = q.SetTokensValidAfter(ctx, SetTokensValidAfterParams{
+			ID:               token.UserID,
+			TokensValidAfter: sql.NullTime{Time: validAfter, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		user, err = q.GetUser(ctx, token.UserID)
+		return err
+	})
+	return user, err
+}
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Function to report a chirp deleted since the caller checked it (a foreign key violation
+// on insert) as sql.ErrNoRows
+func chirpGone(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return sql.ErrNoRows
+	}
+	return err
+}
+
+// Method to like a chirp, incrementing its like count and notifying its author only if the
+// like is new. Returns sql.ErrNoRows if the chirp doesn't exist.
+func (s *Store) LikeChirpTx(ctx context.Context, arg CreateLikeParams) error {
+	return s.execTx(ctx, func(q *Queries) error {
+		n, err := q.CreateLike(ctx, arg)
+		if err != nil || n == 0 {
+			return chirpGone(err)
+		}
+		authorID, err := q.IncrementLikeCount(ctx, arg.ChirpID)
+		if err != nil {
+			return err
+		}
+		return notify(ctx, q, CreateNotificationParams{
+			UserID:  authorID,
+			Type:    NotificationTypeLike,
+			ActorID: uuid.NullUUID{UUID: arg.UserID, Valid: true},
+			ChirpID: uuid.NullUUID{UUID: arg.ChirpID, Valid: true},
+		})
+	})
+}
+
+// Method to unlike a chirp, decrementing its like count only if a like was removed
+func (s *Store) UnlikeChirpTx(ctx context.Context, arg DeleteLikeParams) error {
+	return s.execTx(ctx, func(q *Queries) error {
+		n, err := q.DeleteLike(ctx, arg)
+		if err != nil || n == 0 {
+			return err
+		}
+		return q.DecrementLikeCount(ctx, arg.ChirpID)
+	})
+}
+
+// Method to rechirp a chirp, incrementing its rechirp count only if the rechirp is new.
+// Returns sql.ErrNoRows if the chirp doesn't exist.
+func (s *Store) RechirpTx(ctx context.Context, arg CreateRechirpParams) error {
+	return s.execTx(ctx, func(q *Queries) error {
+		n, err := q.CreateRechirp(ctx, arg)
+		if err != nil || n == 0 {
+			return chirpGone(err)
+		}
+		return q.IncrementRechirpCount(ctx, arg.ChirpID)
+	})
+}
+
+// Method to undo a rechirp, decrementing its rechirp count only if a rechirp was removed
+func (s *Store) UndoRechirpTx(ctx context.Context, arg DeleteRechirpParams) error {
+	return s.execTx(ctx, func(q *Queries) error {
+		n, err := q.DeleteRechirp(ctx, arg)
+		if err != nil || n == 0 {
+			return err
+		}
+		return q.DecrementRechirpCount(ctx, arg.ChirpID)
+	})
+}
@@ -0,0 +1,30 @@
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Method to follow a user, notifying the followee and queueing follow.created webhook
+// deliveries to their endpoints only if the follow is new
+func (s *Store) FollowUserTx(ctx context.Context, arg CreateFollowParams) error {
+	return s.execTx(ctx, func(q *Queries) error {
+		n, err := q.CreateFollow(ctx, arg)
+		if err != nil || n == 0 {
+			return err
+		}
+		err = notify(ctx, q, CreateNotificationParams{
+			UserID:  arg.FolloweeID,
+			Type:    NotificationTypeFollow,
+			ActorID: uuid.NullUUID{UUID: arg.FollowerID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		return queueWebhook(ctx, q, arg.FolloweeID, WebhookEventFollowCreated, map[string]uuid.UUID{
+			"follower_id": arg.FollowerID,
+			"followee_id": arg.FolloweeID,
+		})
+	})
+}
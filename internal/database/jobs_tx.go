@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// Statuses of background jobs. Jobs that fail every attempt, or fail in a way retrying
+// won't fix, are dead-lettered until an admin retries them.
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusDead      = "dead"
+)
+
+// ErrJobNotDead is returned when retrying a job that hasn't been dead-lettered
+var ErrJobNotDead = errors.New("job is not dead")
+
+// Method to put a dead job back in the queue with a fresh set of attempts, recording who
+// retried it. Returns sql.ErrNoRows if the job doesn't exist.
+func (s *Store) RetryDeadJobTx(ctx context.Context, actor AuditActor, jobID uuid.UUID) (Job, error) {
+	var job Job
+	err := s.execTx(ctx, func(q *Queries) error {
+		current, err := q.GetJob(ctx, jobID)
+		if err != nil {
+			return err
+		}
+		if current.Status != JobStatusDead {
+			return ErrJobNotDead
+		}
+		job, err = q.RequeueDeadJob(ctx, jobID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrJobNotDead
+		}
+		if err != nil {
+			return err
+		}
+		return audit(ctx, q, actor, AuditActionJobRetry, AuditTargetJob, jobID, map[string]any{
+			"kind":       job.Kind,
+			"last_error": current.LastError.String,
+		})
+	})
+	return job, err
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// MentionEntity is an @mention resolved to a user, with offsets into the chirp body
+type MentionEntity struct {
+	UserID      uuid.UUID
+	StartOffset int32
+	EndOffset   int32
+}
+
+// ChirpEntities are the hashtags and resolved mentions parsed from a chirp body
+type ChirpEntities struct {
+	Tags     []string
+	Mentions []MentionEntity
+}
+
+// Function to store a chirp's mentions and notify each mentioned user, skipping the
+// author and anyone in alreadyNotified (users mentioned before an edit)
+func setChirpMentions(ctx context.Context, q *Queries, chirp Chirp, mentions []MentionEntity, alreadyNotified map[uuid.UUID]struct{}) error {
+	notified := map[uuid.UUID]struct{}{}
+	for _, mention := range mentions {
+		err := q.CreateMention(ctx, CreateMentionParams{
+			ChirpID:     chirp.ID,
+			UserID:      mention.UserID,
+			StartOffset: mention.StartOffset,
+			EndOffset:   mention.EndOffset,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Notify each mentioned user once
+		if mention.UserID == chirp.UserID {
+			continue
+		}
+		if _, ok := alreadyNotified[mention.UserID]; ok {
+			continue
+		}
+		if _, ok := notified[mention.UserID]; ok {
+			continue
+		}
+		notified[mention.UserID] = struct{}{}
+		err = q.CreateNotification(ctx, CreateNotificationParams{
+			UserID:  mention.UserID,
+			Type:    NotificationTypeMention,
+			ActorID: uuid.NullUUID{UUID: chirp.UserID, Valid: true},
+			ChirpID: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Method to turn on two-factor authentication for a user with a pending TOTP secret,
+// replacing any previous recovery codes with the given hashes
+func (s *Store) EnableTOTPTx(ctx context.Context, userID uuid.UUID, recoveryCodeHashes []string) error {
+	return s.execTx(ctx, func(q *Queries) error {
+		err := q.EnableTOTP(ctx, userID)
+		if err != nil {
+			return err
+		}
+		err = q.DeleteRecoveryCodes(ctx, userID)
+		if err != nil {
+			return err
+		}
+		for _, codeHash := range recoveryCodeHashes {
+			err = q.CreateRecoveryCode(ctx, CreateRecoveryCodeParams{
+				UserID:   userID,
+				CodeHash: codeHash,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Method to turn off two-factor authentication for a user, removing their secret and recovery codes
+func (s *Store) DisableTOTPTx(ctx context.Context, userID uuid.UUID) error {
+	return s.execTx(ctx, func(q *Queries) error {
+		err := q.DisableTOTP(ctx, userID)
+		if err != nil {
+			return err
+		}
+		return q.DeleteRecoveryCodes(ctx, userID)
+	})
+}
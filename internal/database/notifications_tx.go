@@ -0,0 +1,22 @@
+package database
+
+import (
+	"context"
+)
+
+// Notification types stored in notifications.type
+const (
+	NotificationTypeMention   = "mention"
+	NotificationTypeFollow    = "follow"
+	NotificationTypeLike      = "like"
+	NotificationTypeReply     = "reply"
+	NotificationTypeChirpyRed = "chirpy_red"
+)
+
+// Function to create a notification, skipping users acting on their own content
+func notify(ctx context.Context, q *Queries, arg CreateNotificationParams) error {
+	if arg.ActorID.Valid && arg.ActorID.UUID == arg.UserID {
+		return nil
+	}
+	return q.CreateNotification(ctx, arg)
+}
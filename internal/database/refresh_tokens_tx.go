@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrRefreshTokenReused is returned when an already-rotated refresh token is presented
+// again. Its whole token family has been revoked by the time it is returned.
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+// Method to exchange a refresh token for the next one in the same family, revoking the old
+// token. The next token's user and family are taken from the old token. Returns
+// sql.ErrNoRows if the token is unknown, revoked or expired, and ErrRefreshTokenReused
+// (after revoking the family) if it was already rotated.
+func (s *Store) RotateRefreshTokenTx(ctx context.Context, oldToken string, next CreateRefreshTokenParams) (User, error) {
+	var user User
+	reused := false
+	err := s.execTx(ctx, func(q *Queries) error {
+		current, err := q.LockRefreshToken(ctx, oldToken)
+		if err != nil {
+			return err
+		}
+
+		// A rotated token should never be presented again, so assume it was stolen and
+		// end every session descended from the same login
+		if current.RotatedAt.Valid {
+			reused = true
+			return q.RevokeTokenFamily(ctx, current.FamilyID)
+		}
+		if current.RevokedAt.Valid || !current.ExpiresAt.After(time.Now().UTC()) {
+			return sql.ErrNoRows
+		}
+
+		err = q.RotateRefreshToken(ctx, oldToken)
+		if err != nil {
+			return err
+		}
+		next.UserID = current.UserID
+		next.FamilyID = current.FamilyID
+		_, err = q.CreateRefreshToken(ctx, next)
+		if err != nil {
+			return err
+		}
+		user, err = q.GetUser(ctx, current.UserID)
+		return err
+	})
+	if err != nil {
+		return User{}, err
+	}
+	if reused {
+		return User{}, ErrRefreshTokenReused
+	}
+	return user, nil
+}
+
+// Method to log a user out everywhere: every refresh token is revoked and access tokens
+// issued before validAfter stop being accepted
+func (s *Store) RevokeAllSessionsTx(ctx context.Context, userID uuid.UUID, validAfter time.Time) error {
+	return s.execTx(ctx, func(q *Queries) error {
+		err := q.RevokeAllRefreshTokens(ctx, userID)
+		if err != nil {
+			return err
+		}
+		return q.SetTokensValidAfter(ctx, SetTokensValidAfterParams{
+			ID:               userID,
+			TokensValidAfter: sql.NullTime{Time: validAfter, Valid: true},
+		})
+	})
+}
@@ -0,0 +1,38 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Store wraps the generated Queries with the underlying connection so that
+// operations spanning several statements can run in a single transaction.
+type Store struct {
+	*Queries
+	conn *sql.DB
+}
+
+// Function to create a Store from an open database connection
+func NewStore(conn *sql.DB) *Store {
+	return &Store{
+		Queries: New(conn),
+		conn:    conn,
+	}
+}
+
+// Method to run fn inside a transaction, committing on success and rolling back on error
+func (s *Store) execTx(ctx context.Context, fn func(*Queries) error) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	err = fn(s.WithTx(tx))
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("tx err: %v, rollback err: %w", err, rbErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
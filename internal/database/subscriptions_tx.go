@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Subscription statuses. Active, past due and canceled subscriptions keep their perks
+// until they expire.
+const (
+	SubscriptionStatusActive   = "active"
+	SubscriptionStatusPastDue  = "past_due"
+	SubscriptionStatusCanceled = "canceled"
+	SubscriptionStatusExpired  = "expired"
+)
+
+// The only plan Polka sells so far
+const PlanChirpyRed = "red"
+
+// Function to start or renew a user's subscription inside a transaction, granting Chirpy
+// Red. Users are notified whenever they gain Chirpy Red, including on a re-upgrade after it
+// expired, but not on renewals while they still have it.
+func ActivateSubscription(ctx context.Context, q *Queries, userID uuid.UUID, plan string, periodEnd sql.NullTime) (Subscription, error) {
+	user, err := q.LockUser(ctx, userID)
+	if err != nil {
+		return Subscription{}, err
+	}
+	subscription, err := q.ActivateSubscription(ctx, ActivateSubscriptionParams{
+		UserID:           userID,
+		Plan:             plan,
+		CurrentPeriodEnd: periodEnd,
+	})
+	if err != nil {
+		return Subscription{}, err
+	}
+	if user.IsChirpyRed {
+		return subscription, nil
+	}
+	err = q.SetChirpyRed(ctx, SetChirpyRedParams{
+		IsChirpyRed: true,
+		Ids:         []uuid.UUID{userID},
+	})
+	if err != nil {
+		return Subscription{}, err
+	}
+	return subscription, notify(ctx, q, CreateNotificationParams{
+		UserID: userID,
+		Type:   NotificationTypeChirpyRed,
+	})
+}
+
+// Function to mark a subscription past due after a failed payment, inside a transaction.
+// The user keeps Chirpy Red through the grace period while Polka retries the payment.
+// Returns sql.ErrNoRows if the user has no subscription.
+func MarkSubscriptionPastDue(ctx context.Context, q *Queries, userID uuid.UUID) (Subscription, error) {
+	subscription, err := q.LockSubscription(ctx, userID)
+	if err != nil {
+		return Subscription{}, err
+	}
+	if subscription.Status == SubscriptionStatusExpired {
+		return subscription, nil
+	}
+	return q.SetSubscriptionStatus(ctx, SetSubscriptionStatusParams{
+		UserID: userID,
+		Status: SubscriptionStatusPastDue,
+	})
+}
+
+// Function to cancel a subscription inside a transaction. The user keeps Chirpy Red until
+// the period they paid for ends; subscriptions without a period end expire at once.
+// Returns sql.ErrNoRows if the user has no subscription.
+func CancelSubscription(ctx context.Context, q *Queries, userID uuid.UUID, now time.Time) (Subscription, error) {
+	subscription, err := q.LockSubscription(ctx, userID)
+	if err != nil {
+		return Subscription{}, err
+	}
+	if subscription.Status == SubscriptionStatusExpired {
+		return subscription, nil
+	}
+	if !subscription.CurrentPeriodEnd.Valid || !subscription.CurrentPeriodEnd.Time.After(now) {
+		return ExpireSubscription(ctx, q, userID)
+	}
+	return q.SetSubscriptionStatus(ctx, SetSubscriptionStatusParams{
+		UserID: userID,
+		Status: SubscriptionStatusCanceled,
+	})
+}
+
+// Function to end a subscription immediately inside a transaction, as after a refund,
+// removing Chirpy Red. Returns sql.ErrNoRows if the user has no subscription.
+func ExpireSubscription(ctx context.Context, q *Queries, userID uuid.UUID) (Subscription, error) {
+	subscription, err := q.SetSubscriptionStatus(ctx, SetSubscriptionStatusParams{
+		UserID: userID,
+		Status: SubscriptionStatusExpired,
+	})
+	if err != nil {
+		return Subscription{}, err
+	}
+	err = q.SetChirpyRed(ctx, SetChirpyRedParams{
+		IsChirpyRed: false,
+		Ids:         []uuid.UUID{userID},
+	})
+	if err != nil {
+		return Subscription{}, err
+	}
+	return subscription, nil
+}
+
+// Method to expire subscriptions whose period has lapsed and remove their users' Chirpy
+// Red. Canceled subscriptions expire when their period ends; active and past due ones get
+// a grace period for a late renewal. Returns the number of subscriptions expired.
+func (s *Store) ExpireLapsedSubscriptionsTx(ctx context.Context, now time.Time, grace time.Duration) (int, error) {
+	var expired int
+	err := s.execTx(ctx, func(q *Queries) error {
+		userIDs, err := q.ExpireLapsedSubscriptions(ctx, ExpireLapsedSubscriptionsParams{
+			Now:         now,
+			GraceBefore: now.Add(-grace),
+		})
+		if err != nil || len(userIDs) == 0 {
+			return err
+		}
+		expired = len(userIDs)
+		return q.SetChirpyRed(ctx, SetChirpyRedParams{
+			IsChirpyRed: false,
+			Ids:         userIDs,
+		})
+	})
+	return expired, err
+}
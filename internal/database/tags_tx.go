@@ -0,0 +1,36 @@
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Function to link a chirp to each of its (already normalized) hashtags, creating tags as needed
+func setChirpTags(ctx context.Context, q *Queries, chirpID uuid.UUID, tags []string) error {
+	for _, name := range tags {
+		tag, err := q.UpsertTag(ctx, name)
+		if err != nil {
+			return err
+		}
+		err = q.CreateChirpTag(ctx, CreateChirpTagParams{
+			ChirpID: chirpID,
+			TagID:   tag.ID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Method to recompute the trending tags table in one transaction so readers never see it empty
+func (s *Store) RefreshTrendingTagsTx(ctx context.Context, arg InsertTrendingTagsParams) error {
+	return s.execTx(ctx, func(q *Queries) error {
+		err := q.DeleteTrendingTags(ctx)
+		if err != nil {
+			return err
+		}
+		return q.InsertTrendingTags(ctx, arg)
+	})
+}
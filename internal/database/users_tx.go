@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+// UpdateUserTxParams holds the changes to a user's profile and, when UpdateCredentials is
+// set, their email and password hash
+type UpdateUserTxParams struct {
+	ID                uuid.UUID
+	Handle            sql.NullString
+	DisplayName       sql.NullString
+	UpdateCredentials bool
+	Email             string
+	HashedPassword    string
+}
+
+// Method to update a user's handle, display name and credentials together, so a taken
+// handle or email leaves the user unchanged
+func (s *Store) UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (User, error) {
+	var user User
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+		if arg.Handle.Valid {
+			_, err = q.UpdateUserHandle(ctx, UpdateUserHandleParams{
+				ID:     arg.ID,
+				Handle: arg.Handle,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if arg.DisplayName.Valid {
+			_, err = q.UpdateUserDisplayName(ctx, UpdateUserDisplayNameParams{
+				ID:          arg.ID,
+				DisplayName: arg.DisplayName,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if !arg.UpdateCredentials {
+			user, err = q.GetUser(ctx, arg.ID)
+			return err
+		}
+		user, err = q.UpdateUser(ctx, UpdateUserParams{
+			ID:             arg.ID,
+			Email:          arg.Email,
+			HashedPassword: arg.HashedPassword,
+		})
+		return err
+	})
+	return user, err
+}
@@ -0,0 +1,143 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types users can subscribe their webhook endpoints to
+const (
+	WebhookEventChirpCreated  = "chirp.created"
+	WebhookEventChirpDeleted  = "chirp.deleted"
+	WebhookEventFollowCreated = "follow.created"
+)
+
+// WebhookEventTypes lists every event type endpoints can subscribe to
+var WebhookEventTypes = []string{
+	WebhookEventChirpCreated,
+	WebhookEventChirpDeleted,
+	WebhookEventFollowCreated,
+}
+
+// Statuses of outbound webhook deliveries
+const (
+	WebhookDeliveryStatusPending   = "pending"
+	WebhookDeliveryStatusSucceeded = "succeeded"
+	WebhookDeliveryStatusFailed    = "failed"
+)
+
+// Each delivery is sent by a background job of this kind. The job queue's attempts and
+// backoff drive retries, and the delivery is given up on once the job is dead-lettered.
+const (
+	JobKindDeliverWebhook      = "webhook.deliver"
+	WebhookDeliveryMaxAttempts = 8
+)
+
+// Payload of the job sending one delivery
+type WebhookDeliveryJob struct {
+	DeliveryID uuid.UUID `json:"delivery_id"`
+}
+
+// Payload posted to endpoints for every event
+type webhookPayload struct {
+	ID        uuid.UUID `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// Chirp as it appears in webhook payloads
+type webhookChirp struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	Body      string     `json:"body"`
+	UserID    uuid.UUID  `json:"user_id"`
+	InReplyTo *uuid.UUID `json:"in_reply_to,omitempty"`
+}
+
+// Function to queue a delivery of an event to each of a user's enabled endpoints subscribed
+// to it, each with a job to send it. Jobs are only picked up once the surrounding
+// transaction commits.
+func queueWebhook(ctx context.Context, q *Queries, userID uuid.UUID, eventType string, data any) error {
+	payload := webhookPayload{
+		ID:        uuid.New(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}
+	dat, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	deliveries, err := q.EnqueueWebhookDeliveries(ctx, EnqueueWebhookDeliveriesParams{
+		EventID:   payload.ID,
+		EventType: eventType,
+		Payload:   dat,
+		UserID:    userID,
+	})
+	if err != nil {
+		return err
+	}
+	for _, delivery := range deliveries {
+		err = queueDeliveryJob(ctx, q, delivery)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Function to queue the job sending a delivery, due straight away
+func queueDeliveryJob(ctx context.Context, q *Queries, delivery WebhookDelivery) error {
+	dat, err := json.Marshal(WebhookDeliveryJob{DeliveryID: delivery.ID})
+	if err != nil {
+		return err
+	}
+	return q.EnqueueJob(ctx, EnqueueJobParams{
+		Kind:        JobKindDeliverWebhook,
+		Payload:     dat,
+		MaxAttempts: WebhookDeliveryMaxAttempts,
+		RunAt:       time.Now().UTC(),
+	})
+}
+
+// Method to copy a past delivery's event into a new pending delivery to the same endpoint
+// and queue its job. Returns sql.ErrNoRows if the delivery isn't the endpoint's.
+func (s *Store) RedeliverWebhookTx(ctx context.Context, arg RedeliverWebhookParams) (WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+		delivery, err = q.RedeliverWebhook(ctx, arg)
+		if err != nil {
+			return err
+		}
+		return queueDeliveryJob(ctx, q, delivery)
+	})
+	return delivery, err
+}
+
+// Function to queue webhook deliveries for a chirp event to the chirp's author
+func enqueueChirpWebhook(ctx context.Context, q *Queries, eventType string, chirp Chirp) error {
+	switch eventType {
+	case ChirpEventCreated:
+		data := webhookChirp{
+			ID:        chirp.ID,
+			CreatedAt: chirp.CreatedAt,
+			Body:      chirp.Body,
+			UserID:    chirp.UserID,
+		}
+		if chirp.InReplyTo.Valid {
+			data.InReplyTo = &chirp.InReplyTo.UUID
+		}
+		return queueWebhook(ctx, q, chirp.UserID, WebhookEventChirpCreated, data)
+	case ChirpEventDeleted:
+		return queueWebhook(ctx, q, chirp.UserID, WebhookEventChirpDeleted, map[string]uuid.UUID{
+			"id":      chirp.ID,
+			"user_id": chirp.UserID,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"context"
+	"errors"
+)
+
+// Processing outcomes of received webhook events
+const (
+	WebhookEventStatusReceived  = "received"
+	WebhookEventStatusProcessed = "processed"
+	WebhookEventStatusIgnored   = "ignored"
+	WebhookEventStatusFailed    = "failed"
+)
+
+// ErrWebhookEventDone is returned when a webhook event has already been processed or ignored
+var ErrWebhookEventDone = errors.New("webhook event already handled")
+
+// Method to report whether a webhook event needs no further processing
+func (e WebhookEvent) Done() bool {
+	return e.Status == WebhookEventStatusProcessed || e.Status == WebhookEventStatusIgnored
+}
+
+// Method to apply a recorded webhook event and mark it processed in one transaction. The
+// event row is locked first, so concurrent deliveries of the same event apply it once;
+// later ones get ErrWebhookEventDone. If apply fails nothing is changed and the event
+// stays open for a retry.
+func (s *Store) ProcessWebhookEventTx(ctx context.Context, eventID string, apply func(q *Queries) error) error {
+	return s.execTx(ctx, func(q *Queries) error {
+		event, err := q.LockWebhookEvent(ctx, eventID)
+		if err != nil {
+			return err
+		}
+		if event.Done() {
+			return ErrWebhookEventDone
+		}
+		err = apply(q)
+		if err != nil {
+			return err
+		}
+		return q.SetWebhookEventOutcome(ctx, SetWebhookEventOutcomeParams{
+			ID:     eventID,
+			Status: WebhookEventStatusProcessed,
+		})
+	})
+}
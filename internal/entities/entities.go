@@ -0,0 +1,142 @@
+package entities
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Length limits (excluding the leading '#' or '@') for recognized hashtags and handles
+const (
+	MaxHashtagLength = 50
+	MinHandleLength  = 3
+	MaxHandleLength  = 15
+)
+
+// Mention is an @handle found in a chirp body. Start and End are offsets in Unicode
+// code points, with Start at the '@' and End just past the last handle character.
+type Mention struct {
+	Handle string
+	Start  int
+	End    int
+}
+
+// Function to extract the distinct hashtags from a chirp body, normalized to lower case
+// in order of first appearance. A hashtag is '#' followed by letters, digits or
+// underscores, must contain at least one letter, and can't be preceded by a word character.
+func Hashtags(body string) []string {
+	tags := []string{}
+	seen := map[string]struct{}{}
+
+	for i := 0; i < len(body); {
+		r, size := utf8.DecodeRuneInString(body[i:])
+		if r != '#' || (i > 0 && isWordRune(lastRune(body[:i]))) {
+			i += size
+			continue
+		}
+
+		// Consume the tag characters following '#'
+		start := i + size
+		end := start
+		hasLetter := false
+		for end < len(body) {
+			r, size := utf8.DecodeRuneInString(body[end:])
+			if !isWordRune(r) {
+				break
+			}
+			if unicode.IsLetter(r) {
+				hasLetter = true
+			}
+			end += size
+		}
+		i = end
+
+		tag := strings.ToLower(body[start:end])
+		if !hasLetter || utf8.RuneCountInString(tag) > MaxHashtagLength {
+			continue
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+// Function to extract @handle mentions from a chirp body in order of appearance.
+// Handles are normalized to lower case; a mention can't be preceded by a word
+// character, so email addresses aren't treated as mentions.
+func Mentions(body string) []Mention {
+	mentions := []Mention{}
+	runes := []rune(body)
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' || (i > 0 && isWordRune(runes[i-1])) {
+			continue
+		}
+
+		// Consume the handle characters following '@'
+		end := i + 1
+		for end < len(runes) && isHandleRune(runes[end]) {
+			end++
+		}
+
+		// A longer run of word characters isn't a valid handle
+		if end < len(runes) && isWordRune(runes[end]) {
+			i = end
+			continue
+		}
+		handle := string(runes[i+1 : end])
+		if ValidHandle(handle) {
+			mentions = append(mentions, Mention{
+				Handle: strings.ToLower(handle),
+				Start:  i,
+				End:    end,
+			})
+		}
+		i = end - 1
+	}
+
+	return mentions
+}
+
+// Function to report whether a string is a valid handle (without the leading '@')
+func ValidHandle(handle string) bool {
+	if len(handle) < MinHandleLength || len(handle) > MaxHandleLength {
+		return false
+	}
+	for _, r := range handle {
+		if !isHandleRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Function to normalize a handle given by a user to its stored form
+func NormalizeHandle(handle string) string {
+	return strings.ToLower(strings.TrimPrefix(handle, "@"))
+}
+
+// Function to normalize a tag given by a user (e.g. in a URL) to its stored form
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(tag, "#"))
+}
+
+// Function to report whether a rune can be part of a hashtag or handle
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// Function to report whether a rune can be part of a handle (ASCII letters, digits and underscores)
+func isHandleRune(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_'
+}
+
+// Function to get the final rune of a string
+func lastRune(s string) rune {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return r
+}
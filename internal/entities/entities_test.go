@@ -0,0 +1,139 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Unit tests to check hashtag extraction
+func TestHashtags(t *testing.T) {
+
+	// Create a struct for test data
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		// Test 1
+		{
+			name: "No hashtags",
+			body: "Hello from Chirpy!",
+			want: []string{},
+		},
+
+		// Test 2
+		{
+			name: "Hashtags are lower cased and deduplicated",
+			body: "#Go is great, #go #golang",
+			want: []string{"go", "golang"},
+		},
+
+		// Test 3
+		{
+			name: "Hashtag ends at punctuation",
+			body: "Loving #chirpy! (#backend_dev)",
+			want: []string{"chirpy", "backend_dev"},
+		},
+
+		// Test 4
+		{
+			name: "Hash inside a word is ignored",
+			body: "issue a#b and C# code",
+			want: []string{},
+		},
+
+		// Test 5
+		{
+			name: "Numeric only tags are ignored",
+			body: "We're #1 at #2024goals",
+			want: []string{"2024goals"},
+		},
+
+		// Test 6
+		{
+			name: "Unicode letters",
+			body: "#Café time",
+			want: []string{"café"},
+		},
+	}
+
+	// Iterate through each test
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Hashtags(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Hashtags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Unit tests to check mention extraction and offsets
+func TestMentions(t *testing.T) {
+
+	// Create a struct for test data
+	tests := []struct {
+		name string
+		body string
+		want []Mention
+	}{
+		// Test 1
+		{
+			name: "No mentions",
+			body: "Hello from Chirpy!",
+			want: []Mention{},
+		},
+
+		// Test 2
+		{
+			name: "Mentions are lower cased with code point offsets",
+			body: "Hi @Alice and @bob_99!",
+			want: []Mention{
+				{Handle: "alice", Start: 3, End: 9},
+				{Handle: "bob_99", Start: 14, End: 21},
+			},
+		},
+
+		// Test 3
+		{
+			name: "Email addresses are ignored",
+			body: "mail me at someone@example.com",
+			want: []Mention{},
+		},
+
+		// Test 4
+		{
+			name: "Handles that are too short or too long are ignored",
+			body: "@ab @abcdefghijklmnopq @okay",
+			want: []Mention{
+				{Handle: "okay", Start: 23, End: 28},
+			},
+		},
+
+		// Test 5
+		{
+			name: "Offsets count code points, not bytes",
+			body: "Café ☕ @barista",
+			want: []Mention{
+				{Handle: "barista", Start: 7, End: 15},
+			},
+		},
+
+		// Test 6
+		{
+			name: "Handle followed by non-ASCII letter is ignored",
+			body: "@josé",
+			want: []Mention{},
+		},
+	}
+
+	// Iterate through each test
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Mentions(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Mentions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
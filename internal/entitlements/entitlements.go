@@ -0,0 +1,74 @@
+package entitlements
+
+import "time"
+
+// Plans users can be on. Everyone without a current subscription is on the free plan.
+const (
+	PlanFree = "free"
+	PlanRed  = "red"
+)
+
+// Subscription statuses that still grant their plan's perks. Past due subscriptions keep
+// them while a payment is retried and canceled ones until the paid period ends.
+var entitledStatuses = map[string]struct{}{
+	"active":   {},
+	"past_due": {},
+	"canceled": {},
+}
+
+// Limits are what a plan allows. Zero values allow nothing.
+type Limits struct {
+	// Longest chirp body, in bytes
+	MaxChirpLength int
+	// How long after posting a chirp can be edited; zero means chirps can't be edited
+	EditWindow time.Duration
+	// Authenticated API requests allowed per minute
+	RequestsPerMinute int
+}
+
+// Method to report whether the plan allows editing chirps
+func (l Limits) CanEditChirps() bool {
+	return l.EditWindow > 0
+}
+
+// Catalog holds the limits of every plan
+type Catalog map[string]Limits
+
+// Chirpy Red's edit window is this many times the free plan's
+const redEditWindowMultiplier = 4
+
+// Function to create the standard catalog. Free users can edit chirps for editWindow and
+// Chirpy Red users for redEditWindowMultiplier times as long.
+func DefaultCatalog(editWindow time.Duration) Catalog {
+	return Catalog{
+		PlanFree: {
+			MaxChirpLength:    140,
+			EditWindow:        editWindow,
+			RequestsPerMinute: 60,
+		},
+		PlanRed: {
+			MaxChirpLength:    280,
+			EditWindow:        redEditWindowMultiplier * editWindow,
+			RequestsPerMinute: 300,
+		},
+	}
+}
+
+// Subscription is the part of a user's subscription that decides their plan
+type Subscription struct {
+	Plan   string
+	Status string
+}
+
+// Method to get the limits for a user's subscription, which may be nil for users who never
+// subscribed. Lapsed subscriptions and unknown plans get the free plan.
+func (c Catalog) For(sub *Subscription) Limits {
+	if sub != nil {
+		if _, ok := entitledStatuses[sub.Status]; ok {
+			if limits, ok := c[sub.Plan]; ok {
+				return limits
+			}
+		}
+	}
+	return c[PlanFree]
+}
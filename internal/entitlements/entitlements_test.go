@@ -0,0 +1,126 @@
+package entitlements
+
+import (
+	"testing"
+	"time"
+)
+
+// Unit tests to check each perk flips as a subscription moves through its lifecycle
+func TestCatalogFor(t *testing.T) {
+
+	// Catalog used for every case
+	catalog := DefaultCatalog(30 * time.Minute)
+
+	// Create a struct for test data
+	tests := []struct {
+		name        string
+		sub         *Subscription
+		wantLength  int
+		wantPerMin  int
+		wantEditFor time.Duration
+	}{
+		// Test 1
+		{
+			name:        "Never subscribed",
+			sub:         nil,
+			wantLength:  140,
+			wantPerMin:  60,
+			wantEditFor: 30 * time.Minute,
+		},
+
+		// Test 2
+		{
+			name:        "Active subscription",
+			sub:         &Subscription{Plan: PlanRed, Status: "active"},
+			wantLength:  280,
+			wantPerMin:  300,
+			wantEditFor: 2 * time.Hour,
+		},
+
+		// Test 3
+		{
+			name:        "Past due keeps perks while payment is retried",
+			sub:         &Subscription{Plan: PlanRed, Status: "past_due"},
+			wantLength:  280,
+			wantPerMin:  300,
+			wantEditFor: 2 * time.Hour,
+		},
+
+		// Test 4
+		{
+			name:        "Canceled keeps perks until period ends",
+			sub:         &Subscription{Plan: PlanRed, Status: "canceled"},
+			wantLength:  280,
+			wantPerMin:  300,
+			wantEditFor: 2 * time.Hour,
+		},
+
+		// Test 5
+		{
+			name:        "Expired subscription",
+			sub:         &Subscription{Plan: PlanRed, Status: "expired"},
+			wantLength:  140,
+			wantPerMin:  60,
+			wantEditFor: 30 * time.Minute,
+		},
+
+		// Test 6
+		{
+			name:        "Unknown plan",
+			sub:         &Subscription{Plan: "gold", Status: "active"},
+			wantLength:  140,
+			wantPerMin:  60,
+			wantEditFor: 30 * time.Minute,
+		},
+	}
+
+	// Loop through test cases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limits := catalog.For(tt.sub)
+			if limits.MaxChirpLength != tt.wantLength {
+				t.Errorf("MaxChirpLength = %d, want %d", limits.MaxChirpLength, tt.wantLength)
+			}
+			if limits.RequestsPerMinute != tt.wantPerMin {
+				t.Errorf("RequestsPerMinute = %d, want %d", limits.RequestsPerMinute, tt.wantPerMin)
+			}
+			if limits.EditWindow != tt.wantEditFor {
+				t.Errorf("EditWindow = %v, want %v", limits.EditWindow, tt.wantEditFor)
+			}
+			if !limits.CanEditChirps() {
+				t.Errorf("CanEditChirps() = false, want true")
+			}
+		})
+	}
+}
+
+// Unit tests to check a user's perks follow their subscription from upgrade to expiry
+func TestCatalogForLifecycle(t *testing.T) {
+	catalog := DefaultCatalog(time.Hour)
+	sub := &Subscription{Plan: PlanRed}
+
+	// Walk the subscription through each status in order
+	steps := []struct {
+		status    string
+		wantPerks bool
+	}{
+		{status: "active", wantPerks: true},
+		{status: "past_due", wantPerks: true},
+		{status: "active", wantPerks: true},
+		{status: "canceled", wantPerks: true},
+		{status: "expired", wantPerks: false},
+		{status: "active", wantPerks: true},
+	}
+
+	// Loop through test cases
+	for i, step := range steps {
+		sub.Status = step.status
+		limits := catalog.For(sub)
+		if got := limits.EditWindow > catalog[PlanFree].EditWindow; got != step.wantPerks {
+			t.Errorf("Step %d (%s): longer edit window = %v, want %v", i+1, step.status, got, step.wantPerks)
+		}
+		if got := limits.MaxChirpLength > catalog[PlanFree].MaxChirpLength; got != step.wantPerks {
+			t.Errorf("Step %d (%s): longer chirps = %v, want %v", i+1, step.status, got, step.wantPerks)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Failed jobs are retried after a delay that doubles with each attempt, up to a cap
+const (
+	baseRetryDelay = 10 * time.Second
+	maxRetryDelay  = time.Hour
+)
+
+// ErrUnknownKind is returned when no handler is registered for a job's kind
+var ErrUnknownKind = errors.New("no handler registered for job kind")
+
+// Handler runs one job from its raw JSON payload
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// DeadHandler runs once a job is dead-lettered, with the error that killed it
+type DeadHandler func(ctx context.Context, payload json.RawMessage, cause error) error
+
+// Registry maps job kinds to their handlers
+type Registry struct {
+	handlers     map[string]Handler
+	deadHandlers map[string]DeadHandler
+}
+
+// Function to create an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		handlers:     map[string]Handler{},
+		deadHandlers: map[string]DeadHandler{},
+	}
+}
+
+// Function to register a handler whose payload is decoded into T before it runs. A payload
+// that can't be decoded fails permanently, as retrying won't fix it.
+func Register[T any](r *Registry, kind string, fn func(ctx context.Context, payload T) error) {
+	r.handlers[kind] = func(ctx context.Context, raw json.RawMessage) error {
+		var payload T
+		err := json.Unmarshal(raw, &payload)
+		if err != nil {
+			return Permanent(fmt.Errorf("decoding %s payload: %w", kind, err))
+		}
+		return fn(ctx, payload)
+	}
+}
+
+// Function to register a handler run when a job of the kind is dead-lettered, so work the
+// job tracks elsewhere can be marked as given up on. Its payload is decoded into T.
+func OnDead[T any](r *Registry, kind string, fn func(ctx context.Context, payload T, cause error) error) {
+	r.deadHandlers[kind] = func(ctx context.Context, raw json.RawMessage, cause error) error {
+		var payload T
+		err := json.Unmarshal(raw, &payload)
+		if err != nil {
+			return fmt.Errorf("decoding %s payload: %w", kind, err)
+		}
+		return fn(ctx, payload, cause)
+	}
+}
+
+// Method to report whether a handler is registered for a kind
+func (r *Registry) Has(kind string) bool {
+	_, ok := r.handlers[kind]
+	return ok
+}
+
+// Method to run a job with the handler registered for its kind. A panicking handler is
+// reported as an error so the worker survives and the job can be retried.
+func (r *Registry) Run(ctx context.Context, kind string, payload json.RawMessage) (err error) {
+	handler, ok := r.handlers[kind]
+	if !ok {
+		return Permanent(fmt.Errorf("%w: %s", ErrUnknownKind, kind))
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("job panicked: %v", p)
+		}
+	}()
+	return handler(ctx, payload)
+}
+
+// Method to run the dead handler registered for a job's kind, if there is one
+func (r *Registry) Dead(ctx context.Context, kind string, payload json.RawMessage, cause error) error {
+	handler, ok := r.deadHandlers[kind]
+	if !ok {
+		return nil
+	}
+	return handler(ctx, payload, cause)
+}
+
+// Error wrapper marking a failure that retrying won't fix
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e permanentError) Unwrap() error {
+	return e.err
+}
+
+// Function to mark an error as permanent, so the job is dead-lettered without further attempts
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+// Function to report whether an error was marked permanent
+func IsPermanent(err error) bool {
+	var permanent permanentError
+	return errors.As(err, &permanent)
+}
+
+// Function to get the wait before retrying a job that has failed the given number of attempts
+func Backoff(attempts int32) time.Duration {
+	delay := baseRetryDelay
+	for i := int32(1); i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
@@ -0,0 +1,218 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Unit tests to check jobs are dispatched to typed handlers and failures classified
+func TestRegistryRun(t *testing.T) {
+
+	// Payload type used by the registered handler
+	type greeting struct {
+		Name string `json:"name"`
+	}
+	errTransient := errors.New("mail server unavailable")
+
+	// Registry shared by test cases
+	var got string
+	registry := NewRegistry()
+	Register(registry, "greet", func(ctx context.Context, payload greeting) error {
+		if payload.Name == "" {
+			return errTransient
+		}
+		got = payload.Name
+		return nil
+	})
+	Register(registry, "explode", func(ctx context.Context, payload greeting) error {
+		panic("boom")
+	})
+
+	// Create a struct for test data
+	tests := []struct {
+		name          string
+		kind          string
+		payload       string
+		wantName      string
+		wantFail      bool
+		wantErr       error
+		wantPermanent bool
+	}{
+		// Test 1
+		{
+			name:     "Payload decoded for handler",
+			kind:     "greet",
+			payload:  `{"name":"chirpy"}`,
+			wantName: "chirpy",
+		},
+
+		// Test 2
+		{
+			name:     "Handler error can be retried",
+			kind:     "greet",
+			payload:  `{}`,
+			wantFail: true,
+			wantErr:  errTransient,
+		},
+
+		// Test 3
+		{
+			name:          "Undecodable payload fails permanently",
+			kind:          "greet",
+			payload:       `{"name":42}`,
+			wantFail:      true,
+			wantPermanent: true,
+		},
+
+		// Test 4
+		{
+			name:          "Unknown kind fails permanently",
+			kind:          "farewell",
+			payload:       `{}`,
+			wantFail:      true,
+			wantErr:       ErrUnknownKind,
+			wantPermanent: true,
+		},
+
+		// Test 5
+		{
+			name:     "Panic reported as error",
+			kind:     "explode",
+			payload:  `{}`,
+			wantFail: true,
+		},
+	}
+
+	// Loop through test cases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = ""
+			err := registry.Run(context.Background(), tt.kind, json.RawMessage(tt.payload))
+			if (err != nil) != tt.wantFail {
+				t.Fatalf("Run() err = %v, want failure %v", err, tt.wantFail)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Run() err = %v, want %v", err, tt.wantErr)
+			}
+			if IsPermanent(err) != tt.wantPermanent {
+				t.Errorf("IsPermanent() = %v, want %v", IsPermanent(err), tt.wantPermanent)
+			}
+			if got != tt.wantName {
+				t.Errorf("Handler got name %q, want %q", got, tt.wantName)
+			}
+		})
+	}
+}
+
+// Unit tests to check permanent errors keep their cause
+func TestPermanent(t *testing.T) {
+	cause := errors.New("user deleted")
+	err := Permanent(cause)
+	if !IsPermanent(err) || !errors.Is(err, cause) {
+		t.Errorf("Permanent() = %v, want permanent error wrapping %v", err, cause)
+	}
+	if IsPermanent(cause) {
+		t.Errorf("IsPermanent() = true for plain error")
+	}
+	if Permanent(nil) != nil {
+		t.Errorf("Permanent(nil) = %v, want nil", Permanent(nil))
+	}
+}
+
+// Unit tests to check retry delays double up to the cap
+func TestBackoff(t *testing.T) {
+
+	// Create a struct for test data
+	tests := []struct {
+		attempts int32
+		want     time.Duration
+	}{
+		// Test 1
+		{attempts: 1, want: 10 * time.Second},
+		// Test 2
+		{attempts: 2, want: 20 * time.Second},
+		// Test 3
+		{attempts: 5, want: 160 * time.Second},
+		// Test 4
+		{attempts: 20, want: time.Hour},
+	}
+
+	// Loop through test cases
+	for _, tt := range tests {
+		if got := Backoff(tt.attempts); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// Unit tests to check dead handlers run with the payload and cause of a dead job
+func TestRegistryDead(t *testing.T) {
+
+	// Payload type used by the registered handler
+	type delivery struct {
+		ID string `json:"id"`
+	}
+	cause := errors.New("endpoint returned 503")
+
+	// Registry shared by test cases
+	var gotID string
+	var gotCause error
+	registry := NewRegistry()
+	OnDead(registry, "deliver", func(ctx context.Context, payload delivery, err error) error {
+		gotID = payload.ID
+		gotCause = err
+		return nil
+	})
+
+	// Create a struct for test data
+	tests := []struct {
+		name    string
+		kind    string
+		payload string
+		wantID  string
+		wantErr bool
+	}{
+		// Test 1
+		{
+			name:    "Dead handler runs",
+			kind:    "deliver",
+			payload: `{"id":"d1"}`,
+			wantID:  "d1",
+		},
+
+		// Test 2
+		{
+			name:    "Kind without a dead handler",
+			kind:    "greet",
+			payload: `{"id":"d2"}`,
+		},
+
+		// Test 3
+		{
+			name:    "Malformed payload",
+			kind:    "deliver",
+			payload: `{"id":`,
+			wantErr: true,
+		},
+	}
+
+	// Loop through test cases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotID, gotCause = "", nil
+			err := registry.Dead(context.Background(), tt.kind, json.RawMessage(tt.payload), cause)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Dead() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if gotID != tt.wantID {
+				t.Errorf("Dead() handler got ID %q, want %q", gotID, tt.wantID)
+			}
+			if tt.wantID != "" && gotCause != cause {
+				t.Errorf("Dead() handler got cause %v, want %v", gotCause, cause)
+			}
+		})
+	}
+}
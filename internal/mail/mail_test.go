@@ -0,0 +1,99 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Unit tests to check messages are formatted with safe headers and CRLF line endings
+func TestFormat(t *testing.T) {
+	date := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	// Create a struct for test data
+	tests := []struct {
+		name     string
+		msg      Message
+		contains []string
+		excludes []string
+	}{
+		// Test 1
+		{
+			name: "Plain message",
+			msg: Message{
+				To:      "user@example.com",
+				Subject: "Hello",
+				Body:    "Line one\nLine two",
+			},
+			contains: []string{
+				"From: Chirpy <noreply@chirpy.example>\r\n",
+				"To: user@example.com\r\n",
+				"Subject: Hello\r\n",
+				"Date: Tue, 02 Jan 2024 03:04:05 +0000\r\n",
+				"\r\n\r\nLine one\r\nLine two\r\n",
+			},
+		},
+
+		// Test 2
+		{
+			name: "Header injection stripped",
+			msg: Message{
+				To:      "user@example.com\r\nBcc: victim@example.com",
+				Subject: "Hi\nBcc: victim@example.com",
+			},
+			excludes: []string{"\r\nBcc:", "\nBcc:"},
+		},
+
+		// Test 3
+		{
+			name: "Non-ASCII subject encoded",
+			msg: Message{
+				To:      "user@example.com",
+				Subject: "Café",
+			},
+			contains: []string{"Subject: =?utf-8?q?Caf=C3=A9?=\r\n"},
+		},
+	}
+
+	// Loop through test cases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(Format("Chirpy <noreply@chirpy.example>", tt.msg, date))
+			for _, want := range tt.contains {
+				if !strings.Contains(got, want) {
+					t.Errorf("Format() = %q, missing %q", got, want)
+				}
+			}
+			for _, bad := range tt.excludes {
+				if strings.Contains(got, bad) {
+					t.Errorf("Format() = %q, contains %q", got, bad)
+				}
+			}
+		})
+	}
+}
+
+// Unit test to check the file and in-memory mailers keep what was sent
+func TestLocalMailers(t *testing.T) {
+	msg := Message{To: "user@example.com", Subject: "Verify", Body: "token"}
+
+	memory := &MemoryMailer{}
+	if err := memory.Send(context.Background(), msg); err != nil {
+		t.Fatalf("MemoryMailer.Send() error = %v", err)
+	}
+	if got := memory.Messages(); len(got) != 1 || got[0] != msg {
+		t.Errorf("MemoryMailer.Messages() = %v, want [%v]", got, msg)
+	}
+
+	dir := t.TempDir()
+	file := FileMailer{Dir: dir, From: "noreply@chirpy.example"}
+	if err := file.Send(context.Background(), msg); err != nil {
+		t.Fatalf("FileMailer.Send() error = %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 || !strings.HasSuffix(entries[0].Name(), ".eml") {
+		t.Fatalf("FileMailer wrote %v, %v", entries, err)
+	}
+}
@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Result describes a counted request
+type Result struct {
+	// Whether the request is within the limit
+	Allowed bool
+	// Requests left in the current window
+	Remaining int
+	// When the current window ends and counts start again
+	ResetAt time.Time
+}
+
+// Limiter counts requests per key in fixed windows, in memory. Counts for every key are
+// dropped together when a new window starts, so memory is bounded by one window's keys.
+type Limiter struct {
+	mu          sync.Mutex
+	window      time.Duration
+	windowStart time.Time
+	counts      map[string]int
+}
+
+// Function to create a limiter with the given window length
+func New(window time.Duration) *Limiter {
+	return &Limiter{
+		window: window,
+		counts: map[string]int{},
+	}
+}
+
+// Method to count a request for key at now and report whether it's within limit
+func (l *Limiter) Allow(key string, limit int, now time.Time) Result {
+	windowStart := now.Truncate(l.window)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !windowStart.Equal(l.windowStart) {
+		l.windowStart = windowStart
+		clear(l.counts)
+	}
+	l.counts[key]++
+	count := l.counts[key]
+
+	return Result{
+		Allowed:   count <= limit,
+		Remaining: max(limit-count, 0),
+		ResetAt:   windowStart.Add(l.window),
+	}
+}
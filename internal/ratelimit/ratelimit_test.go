@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// Unit tests to check requests are counted per key and reset each window
+func TestAllow(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Create a struct for test data
+	tests := []struct {
+		name          string
+		key           string
+		at            time.Time
+		wantAllowed   bool
+		wantRemaining int
+	}{
+		// Test 1
+		{
+			name:          "First request",
+			key:           "a",
+			at:            start,
+			wantAllowed:   true,
+			wantRemaining: 1,
+		},
+
+		// Test 2
+		{
+			name:          "Last request within limit",
+			key:           "a",
+			at:            start.Add(10 * time.Second),
+			wantAllowed:   true,
+			wantRemaining: 0,
+		},
+
+		// Test 3
+		{
+			name:          "Over limit",
+			key:           "a",
+			at:            start.Add(20 * time.Second),
+			wantAllowed:   false,
+			wantRemaining: 0,
+		},
+
+		// Test 4
+		{
+			name:          "Other key counted separately",
+			key:           "b",
+			at:            start.Add(30 * time.Second),
+			wantAllowed:   true,
+			wantRemaining: 1,
+		},
+
+		// Test 5
+		{
+			name:          "Next window starts again",
+			key:           "a",
+			at:            start.Add(time.Minute),
+			wantAllowed:   true,
+			wantRemaining: 1,
+		},
+	}
+
+	// Cases run in order against one limiter
+	limiter := New(time.Minute)
+
+	// Loop through test cases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := limiter.Allow(tt.key, 2, tt.at)
+			if got.Allowed != tt.wantAllowed {
+				t.Errorf("Allow() Allowed = %v, want %v", got.Allowed, tt.wantAllowed)
+			}
+			if got.Remaining != tt.wantRemaining {
+				t.Errorf("Allow() Remaining = %d, want %d", got.Remaining, tt.wantRemaining)
+			}
+			wantReset := tt.at.Truncate(time.Minute).Add(time.Minute)
+			if !got.ResetAt.Equal(wantReset) {
+				t.Errorf("Allow() ResetAt = %v, want %v", got.ResetAt, wantReset)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package search
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Date layout accepted by the since: and until: operators
+const DateLayout = "2006-01-02"
+
+// Query is a chirp search split into its full-text terms and filter operators
+type Query struct {
+	Text  string
+	From  string
+	Since time.Time
+	Until time.Time
+}
+
+// Function to parse a chirp search such as `"hello world" -spam from:alice since:2024-01-01`.
+// Operators are removed and everything else is kept as text for websearch_to_tsquery, so
+// quoted phrases, OR and -exclusions work as in Postgres. until: is exclusive.
+func Parse(q string) (Query, error) {
+	query := Query{}
+	terms := []string{}
+
+	for _, token := range tokenize(q) {
+		key, value, found := strings.Cut(token, ":")
+		if !found || strings.HasPrefix(token, `"`) {
+			terms = append(terms, token)
+			continue
+		}
+
+		switch strings.ToLower(key) {
+		case "from":
+			query.From = strings.ToLower(strings.TrimPrefix(value, "@"))
+			if query.From == "" {
+				return Query{}, errors.New("from: needs a handle")
+			}
+		case "since":
+			since, err := time.Parse(DateLayout, value)
+			if err != nil {
+				return Query{}, errors.New("since: must be a date like 2006-01-02")
+			}
+			query.Since = since
+		case "until":
+			until, err := time.Parse(DateLayout, value)
+			if err != nil {
+				return Query{}, errors.New("until: must be a date like 2006-01-02")
+			}
+			query.Until = until
+		default:
+			terms = append(terms, token)
+		}
+	}
+
+	query.Text = strings.Join(terms, " ")
+	if query.Text == "" {
+		return Query{}, errors.New("search needs at least one term")
+	}
+	if !query.Since.IsZero() && !query.Until.IsZero() && !query.Since.Before(query.Until) {
+		return Query{}, errors.New("since: must be before until:")
+	}
+	return query, nil
+}
+
+// Function to split a search on whitespace, keeping quoted phrases together
+func tokenize(q string) []string {
+	tokens := []string{}
+	var current strings.Builder
+	inQuotes := false
+
+	for _, r := range q {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			current.WriteRune(r)
+		case !inQuotes && (r == ' ' || r == '\t' || r == '\n'):
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens
+}
+
+// Function to turn a user search into a case-insensitive LIKE prefix pattern, escaping
+// LIKE wildcards so handles containing '_' match literally
+func PrefixPattern(q string) string {
+	q = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(q), "@"))
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(q) + "%"
+}
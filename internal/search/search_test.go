@@ -0,0 +1,147 @@
+package search
+
+import (
+	"testing"
+	"time"
+)
+
+// Unit tests to check chirp searches are split into text and operators
+func TestParse(t *testing.T) {
+
+	// Create a struct for test data
+	tests := []struct {
+		name    string
+		q       string
+		want    Query
+		wantErr bool
+	}{
+		// Test 1
+		{
+			name: "Plain terms",
+			q:    "hello world",
+			want: Query{Text: "hello world"},
+		},
+
+		// Test 2
+		{
+			name: "Quoted phrase is kept together",
+			q:    `"hello world" -spam`,
+			want: Query{Text: `"hello world" -spam`},
+		},
+
+		// Test 3
+		{
+			name: "Author filter with @ and mixed case",
+			q:    "golang from:@Alice",
+			want: Query{Text: "golang", From: "alice"},
+		},
+
+		// Test 4
+		{
+			name: "Date range",
+			q:    "since:2024-01-01 until:2024-02-01 release",
+			want: Query{
+				Text:  "release",
+				Since: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				Until: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+
+		// Test 5
+		{
+			name: "Colon inside a phrase is not an operator",
+			q:    `"from:bob"`,
+			want: Query{Text: `"from:bob"`},
+		},
+
+		// Test 6
+		{
+			name: "Unknown operator is kept as text",
+			q:    "https://example.com",
+			want: Query{Text: "https://example.com"},
+		},
+
+		// Test 7
+		{
+			name:    "Only operators",
+			q:       "from:alice",
+			wantErr: true,
+		},
+
+		// Test 8
+		{
+			name:    "Invalid date",
+			q:       "news since:yesterday",
+			wantErr: true,
+		},
+
+		// Test 9
+		{
+			name:    "Since after until",
+			q:       "news since:2024-02-01 until:2024-01-01",
+			wantErr: true,
+		},
+
+		// Test 10
+		{
+			name:    "Empty search",
+			q:       "   ",
+			wantErr: true,
+		},
+	}
+
+	// Loop through test cases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.q)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.Text != tt.want.Text || got.From != tt.want.From ||
+				!got.Since.Equal(tt.want.Since) || !got.Until.Equal(tt.want.Until) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Unit tests to check user searches become escaped prefix patterns
+func TestPrefixPattern(t *testing.T) {
+
+	// Create a struct for test data
+	tests := []struct {
+		name string
+		q    string
+		want string
+	}{
+		// Test 1
+		{
+			name: "Lowercased prefix",
+			q:    "Ali",
+			want: "ali%",
+		},
+
+		// Test 2
+		{
+			name: "Leading @ and whitespace removed",
+			q:    " @bob ",
+			want: "bob%",
+		},
+
+		// Test 3
+		{
+			name: "Wildcards escaped",
+			q:    `a_b%c\`,
+			want: `a\_b\%c\\%`,
+		},
+	}
+
+	// Loop through test cases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PrefixPattern(tt.q); got != tt.want {
+				t.Errorf("PrefixPattern() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
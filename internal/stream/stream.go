@@ -0,0 +1,86 @@
+package stream
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Event is a single message pushed to subscribers
+type Event struct {
+	ID     int64
+	Type   string
+	UserID uuid.UUID
+	Data   []byte
+}
+
+// Filter decides whether a subscriber receives an event. A nil filter receives everything.
+type Filter func(Event) bool
+
+// Subscription is a single subscriber's view of the hub
+type Subscription struct {
+	events chan Event
+	filter Filter
+}
+
+// Hub fans published events out to every matching subscriber
+type Hub struct {
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+}
+
+// Function to create an empty hub
+func NewHub() *Hub {
+	return &Hub{
+		subs: map[*Subscription]struct{}{},
+	}
+}
+
+// Method to add a subscriber with room for buffer undelivered events
+func (h *Hub) Subscribe(filter Filter, buffer int) *Subscription {
+	sub := &Subscription{
+		events: make(chan Event, buffer),
+		filter: filter,
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subs[sub] = struct{}{}
+	return sub
+}
+
+// Method to remove a subscriber and close its channel. Safe to call more than once.
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	close(sub.events)
+}
+
+// Method to send an event to every matching subscriber without blocking. Subscribers
+// whose buffer is full are dropped (their channel is closed) so one slow client can't
+// hold up the rest; they are expected to reconnect and resume.
+func (h *Hub) Publish(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs {
+		if sub.filter != nil && !sub.filter(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			delete(h.subs, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// Method to get the channel of events for a subscriber. It is closed on unsubscribe
+// or when the subscriber falls too far behind.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
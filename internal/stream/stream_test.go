@@ -0,0 +1,100 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// Unit tests to check events are delivered to matching subscribers
+func TestPublish(t *testing.T) {
+
+	// Create some users to filter on
+	user1 := uuid.New()
+	user2 := uuid.New()
+
+	// Create a struct for test data
+	tests := []struct {
+		name    string
+		filter  Filter
+		events  []Event
+		wantIDs []int64
+	}{
+		// Test 1
+		{
+			name:    "No filter receives everything",
+			filter:  nil,
+			events:  []Event{{ID: 1, UserID: user1}, {ID: 2, UserID: user2}},
+			wantIDs: []int64{1, 2},
+		},
+
+		// Test 2
+		{
+			name:    "Filter by user",
+			filter:  func(e Event) bool { return e.UserID == user2 },
+			events:  []Event{{ID: 1, UserID: user1}, {ID: 2, UserID: user2}, {ID: 3, UserID: user1}},
+			wantIDs: []int64{2},
+		},
+
+		// Test 3
+		{
+			name:    "Filter matching nothing",
+			filter:  func(e Event) bool { return false },
+			events:  []Event{{ID: 1, UserID: user1}},
+			wantIDs: []int64{},
+		},
+	}
+
+	// Loop through test cases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewHub()
+			sub := hub.Subscribe(tt.filter, 10)
+			for _, event := range tt.events {
+				hub.Publish(event)
+			}
+			hub.Unsubscribe(sub)
+
+			gotIDs := []int64{}
+			for event := range sub.Events() {
+				gotIDs = append(gotIDs, event.ID)
+			}
+			if len(gotIDs) != len(tt.wantIDs) {
+				t.Fatalf("got IDs %v, want %v", gotIDs, tt.wantIDs)
+			}
+			for i := range gotIDs {
+				if gotIDs[i] != tt.wantIDs[i] {
+					t.Errorf("got IDs %v, want %v", gotIDs, tt.wantIDs)
+				}
+			}
+		})
+	}
+}
+
+// Unit test to check slow subscribers are dropped without blocking others
+func TestPublishDropsSlowSubscriber(t *testing.T) {
+	hub := NewHub()
+	slow := hub.Subscribe(nil, 1)
+	fast := hub.Subscribe(nil, 10)
+
+	hub.Publish(Event{ID: 1})
+	hub.Publish(Event{ID: 2})
+
+	// Slow subscriber keeps the event it had room for, then its channel is closed
+	event, ok := <-slow.Events()
+	if !ok || event.ID != 1 {
+		t.Fatalf("got %v (open %v), want event 1", event, ok)
+	}
+	if _, ok := <-slow.Events(); ok {
+		t.Errorf("expected slow subscriber to be closed")
+	}
+
+	// Fast subscriber receives everything
+	if len(fast.Events()) != 2 {
+		t.Errorf("got %d events for fast subscriber, want 2", len(fast.Events()))
+	}
+
+	// Unsubscribing a dropped subscriber is a no-op
+	hub.Unsubscribe(slow)
+	hub.Unsubscribe(fast)
+}
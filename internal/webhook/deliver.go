@@ -0,0 +1,191 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// Headers sent with every outbound delivery
+const (
+	SignatureHeaderName = "Chirpy-Signature"
+	EventHeaderName     = "Chirpy-Event"
+	DeliveryHeaderName  = "Chirpy-Delivery"
+)
+
+// Only the start of a response body is kept in the delivery log
+const maxResponseBodyBytes = 1024
+
+// ErrPrivateAddress is returned when a delivery would connect to a loopback, private,
+// link-local or other non-public address
+var ErrPrivateAddress = errors.New("webhook URL resolves to a private address")
+
+// Function to create a random endpoint signing secret
+func NewSecret() (string, error) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	if err != nil {
+		return "", err
+	}
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(key), nil
+}
+
+// Function to validate an endpoint URL. Plain HTTP is only accepted when allowHTTP is set,
+// for local development.
+func ValidateURL(rawURL string, allowHTTP bool) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return errors.New("Invalid webhook URL")
+	}
+	if u.Scheme != "https" && !(allowHTTP && u.Scheme == "http") {
+		return errors.New("Webhook URL must use https")
+	}
+	if u.Hostname() == "" || u.User != nil {
+		return errors.New("Invalid webhook URL")
+	}
+	return nil
+}
+
+// Delivery is a single signed POST of an event to an endpoint
+type Delivery struct {
+	ID        string
+	EventType string
+	Payload   []byte
+}
+
+// Result is the outcome of one delivery attempt
+type Result struct {
+	StatusCode int
+	Body       string
+	Err        error
+}
+
+// Method to report whether the endpoint accepted the delivery with a 2xx response
+func (r Result) OK() bool {
+	return r.Err == nil && r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// Sender posts signed deliveries to endpoints
+type Sender struct {
+	Client *http.Client
+	Now    func() time.Time
+}
+
+// Function to create a sender whose requests time out and never follow redirects. Unless
+// allowPrivate is set, connections to loopback, private and link-local addresses are
+// refused so users can't point webhooks at internal services.
+func NewSender(timeout time.Duration, allowPrivate bool) *Sender {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = refusePrivateAddress
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil
+	return &Sender{
+		Client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		Now: time.Now,
+	}
+}
+
+// Addresses deliveries may never connect to: loopback, private, shared, link-local,
+// multicast, reserved and documentation ranges, and IPv6 prefixes that embed or translate
+// to IPv4 addresses. IPv4-mapped IPv6 addresses are unmapped before checking.
+var deniedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("192.88.99.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001::/32"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("2002::/16"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("fec0::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+// Function to report whether an address is in one of the denied ranges
+func isPrivateAddress(addr netip.Addr) bool {
+	addr = addr.Unmap().WithZone("")
+	for _, prefix := range deniedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Function to refuse a connection once its address is resolved, so DNS can't be used to
+// sneak past the check
+func refusePrivateAddress(network, address string, c syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return ErrPrivateAddress
+	}
+	if isPrivateAddress(addrPort.Addr()) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// Method to post a delivery to an endpoint, signed with its secret
+func (s *Sender) Send(ctx context.Context, endpointURL, secret string, d Delivery) Result {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpointURL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return Result{Err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Chirpy-Webhooks/1.0")
+	req.Header.Set(EventHeaderName, d.EventType)
+	req.Header.Set(DeliveryHeaderName, d.ID)
+	req.Header.Set(SignatureHeaderName, SignatureHeader(secret, s.Now(), d.Payload))
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return Result{Err: err}
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBodyBytes))
+	result := Result{
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+	}
+	if !result.OK() {
+		result.Err = fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signature header values look like "t=<unix seconds>,v1=<hex HMAC-SHA256>". The HMAC is
+// taken over "<t>.<raw body>", so the timestamp can't be changed without the secret.
+// Several v1 values may be sent while a secret is being rotated.
+
+// Errors returned when a signature can't be verified
+var (
+	ErrMissingSignature  = errors.New("missing webhook signature")
+	ErrMalformedHeader   = errors.New("malformed webhook signature header")
+	ErrTimestampTooOld   = errors.New("webhook timestamp outside tolerance")
+	ErrSignatureMismatch = errors.New("webhook signature doesn't match")
+)
+
+// Function to compute the hex HMAC-SHA256 of a body sent at a timestamp
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp.Unix())
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Function to build the signature header value for a body sent at a timestamp
+func SignatureHeader(secret string, timestamp time.Time, body []byte) string {
+	return fmt.Sprintf("t=%d,v1=%s", timestamp.Unix(), Sign(secret, timestamp, body))
+}
+
+// Function to verify a signature header against the raw body. The timestamp must be within
+// tolerance of now in either direction, which limits how long a captured request can be
+// replayed.
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	if header == "" {
+		return ErrMissingSignature
+	}
+
+	// Gather timestamp and candidate signatures, ignoring unknown schemes
+	var timestamp time.Time
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return ErrMalformedHeader
+		}
+		switch key {
+		case "t":
+			unix, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return ErrMalformedHeader
+			}
+			timestamp = time.Unix(unix, 0)
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if timestamp.IsZero() || len(signatures) == 0 {
+		return ErrMalformedHeader
+	}
+
+	if now.Sub(timestamp).Abs() > tolerance {
+		return ErrTimestampTooOld
+	}
+
+	expected := []byte(Sign(secret, timestamp, body))
+	for _, signature := range signatures {
+		if hmac.Equal(expected, []byte(signature)) {
+			return nil
+		}
+	}
+	return ErrSignatureMismatch
+}
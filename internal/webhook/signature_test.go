@@ -0,0 +1,121 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// Unit tests to check webhook signatures are verified over the body and timestamp
+func TestVerify(t *testing.T) {
+
+	// Values shared by test cases
+	secret := "whsec_test"
+	body := []byte(`{"id":"evt_1","event":"user.upgraded"}`)
+	now := time.Unix(1_700_000_000, 0)
+	tolerance := 5 * time.Minute
+
+	// Create a struct for test data
+	tests := []struct {
+		name    string
+		header  string
+		body    []byte
+		wantErr error
+	}{
+		// Test 1
+		{
+			name:   "Valid signature",
+			header: SignatureHeader(secret, now, body),
+			body:   body,
+		},
+
+		// Test 2
+		{
+			name:   "Timestamp within tolerance",
+			header: SignatureHeader(secret, now.Add(-4*time.Minute), body),
+			body:   body,
+		},
+
+		// Test 3
+		{
+			name:    "Timestamp too old",
+			header:  SignatureHeader(secret, now.Add(-6*time.Minute), body),
+			body:    body,
+			wantErr: ErrTimestampTooOld,
+		},
+
+		// Test 4
+		{
+			name:    "Timestamp too far in the future",
+			header:  SignatureHeader(secret, now.Add(6*time.Minute), body),
+			body:    body,
+			wantErr: ErrTimestampTooOld,
+		},
+
+		// Test 5
+		{
+			name:    "Body changed",
+			header:  SignatureHeader(secret, now, body),
+			body:    []byte(`{"id":"evt_1","event":"user.downgraded"}`),
+			wantErr: ErrSignatureMismatch,
+		},
+
+		// Test 6
+		{
+			name:    "Timestamp changed",
+			header:  fmt.Sprintf("t=%d,v1=%s", now.Unix()+1, Sign(secret, now, body)),
+			body:    body,
+			wantErr: ErrSignatureMismatch,
+		},
+
+		// Test 7
+		{
+			name:    "Wrong secret",
+			header:  SignatureHeader("other", now, body),
+			body:    body,
+			wantErr: ErrSignatureMismatch,
+		},
+
+		// Test 8
+		{
+			name:   "One of several signatures matches",
+			header: fmt.Sprintf("t=%d,v1=%s,v1=%s", now.Unix(), Sign("old", now, body), Sign(secret, now, body)),
+			body:   body,
+		},
+
+		// Test 9
+		{
+			name:    "Missing header",
+			header:  "",
+			body:    body,
+			wantErr: ErrMissingSignature,
+		},
+
+		// Test 10
+		{
+			name:    "Missing timestamp",
+			header:  "v1=" + Sign(secret, now, body),
+			body:    body,
+			wantErr: ErrMalformedHeader,
+		},
+
+		// Test 11
+		{
+			name:    "Malformed timestamp",
+			header:  "t=soon,v1=" + Sign(secret, now, body),
+			body:    body,
+			wantErr: ErrMalformedHeader,
+		},
+	}
+
+	// Loop through test cases
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(secret, tt.header, tt.body, tolerance, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
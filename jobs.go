@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"chirpy/internal/database"
+	"chirpy/internal/jobs"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of background job, each with a typed payload
+const (
+	jobKindSendEmail          = "email.send"
+	jobKindEmailVerification  = "email.verification"
+	jobKindPasswordReset      = "email.password_reset"
+	jobKindCleanupExpiredData = "cleanup.expired_data"
+	jobKindPruneLoginThrottle = "cleanup.login_throttles"
+	jobKindPruneChirpEvents   = "cleanup.chirp_events"
+	jobKindRefreshTrending    = "trending.refresh"
+	jobKindExpireSubscription = "subscriptions.expire"
+	jobKindRecomputeCounters  = "counters.recompute"
+	jobKindDeliverWebhook     = database.JobKindDeliverWebhook
+)
+
+// Settings for the job workers. A claimed job is leased so other workers skip it; if the
+// server dies mid-job the lease runs out and the job is picked up again. Handlers must
+// finish well within the lease.
+const (
+	jobWorkers            = 4
+	jobPollInterval       = time.Second
+	jobLease              = 5 * time.Minute
+	jobTimeout            = 4 * time.Minute
+	jobDefaultMaxAttempts = 5
+)
+
+// Expired data is cleaned up hourly. Succeeded jobs are kept for a week for inspection;
+// dead jobs are kept until retried.
+const (
+	cleanupInterval      = time.Hour
+	succeededJobsKeptFor = 7 * 24 * time.Hour
+)
+
+// Recurring jobs are scheduled every minute, which must be no longer than the shortest
+// recurring job's interval
+const jobScheduleInterval = time.Minute
+
+// Struct for a job kind queued once per interval
+type recurringJob struct {
+	kind     string
+	interval time.Duration
+}
+
+// Jobs every server instance schedules, each run once per interval across all instances
+var recurringJobs = []recurringJob{
+	{kind: jobKindCleanupExpiredData, interval: cleanupInterval},
+	{kind: jobKindPruneLoginThrottle, interval: loginThrottlePruneInterval},
+	{kind: jobKindPruneChirpEvents, interval: streamPruneInterval},
+	{kind: jobKindRefreshTrending, interval: trendingRefreshInterval},
+	{kind: jobKindExpireSubscription, interval: subscriptionExpiryInterval},
+	{kind: jobKindRecomputeCounters, interval: counterRecomputeInterval},
+}
+
+// Payload of jobs that act on one user
+type userJobPayload struct {
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// Method to queue a job to run at runAt. Jobs with a unique key are only queued once, so
+// several server instances can schedule the same job safely.
+func (cfg *apiConfig) enqueueJob(ctx context.Context, kind string, payload any, runAt time.Time, uniqueKey string) error {
+	dat, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return cfg.db.EnqueueJob(ctx, database.EnqueueJobParams{
+		Kind:        kind,
+		Payload:     dat,
+		UniqueKey:   sql.NullString{String: uniqueKey, Valid: uniqueKey != ""},
+		MaxAttempts: jobDefaultMaxAttempts,
+		RunAt:       runAt.UTC(),
+	})
+}
+
+// Method to build the registry of job handlers
+func (cfg *apiConfig) newJobRegistry() *jobs.Registry {
+	registry := jobs.NewRegistry()
+	jobs.Register(registry, jobKindSendEmail, cfg.mailer.Send)
+	jobs.Register(registry, jobKindEmailVerification, cfg.runEmailVerificationJob)
+	jobs.Register(registry, jobKindPasswordReset, cfg.runPasswordResetJob)
+	jobs.Register(registry, jobKindCleanupExpiredData, cfg.runCleanupJob)
+	jobs.Register(registry, jobKindPruneLoginThrottle, cfg.runLoginThrottlePruneJob)
+	jobs.Register(registry, jobKindPruneChirpEvents, cfg.runChirpEventPruneJob)
+	jobs.Register(registry, jobKindRefreshTrending, cfg.runTrendingJob)
+	jobs.Register(registry, jobKindExpireSubscription, cfg.runSubscriptionExpiryJob)
+	jobs.Register(registry, jobKindRecomputeCounters, cfg.runCounterRecomputeJob)
+	jobs.Register(registry, jobKindDeliverWebhook, cfg.runWebhookDeliveryJob)
+	jobs.OnDead(registry, jobKindDeliverWebhook, cfg.failWebhookDelivery)
+	return registry
+}
+
+// Method to get the user a job acts on. A user deleted since the job was queued fails it
+// permanently.
+func (cfg *apiConfig) jobUser(ctx context.Context, payload userJobPayload) (database.User, error) {
+	user, err := cfg.db.GetUser(ctx, payload.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, jobs.Permanent(err)
+	}
+	return user, err
+}
+
+// Method to email a user a verification link, unless their address was verified meanwhile
+func (cfg *apiConfig) runEmailVerificationJob(ctx context.Context, payload userJobPayload) error {
+	user, err := cfg.jobUser(ctx, payload)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt.Valid {
+		return nil
+	}
+	return cfg.sendEmailVerification(ctx, user)
+}
+
+// Method to email a user a password reset token
+func (cfg *apiConfig) runPasswordResetJob(ctx context.Context, payload userJobPayload) error {
+	user, err := cfg.jobUser(ctx, payload)
+	if err != nil {
+		return err
+	}
+	return cfg.sendPasswordReset(ctx, user)
+}
+
+// Function to get the unique key of a recurring job's run for the interval containing t
+func recurringJobKey(job recurringJob, t time.Time) string {
+	return job.kind + ":" + t.UTC().Truncate(job.interval).Format(time.RFC3339)
+}
+
+// Method to queue each recurring job for its current and next intervals if no instance
+// has yet. Queuing the next one ahead means it's due right as its interval starts.
+func (cfg *apiConfig) scheduleRecurringJobs(ctx context.Context) error {
+	now := time.Now().UTC()
+	for _, job := range recurringJobs {
+		next := now.Truncate(job.interval).Add(job.interval)
+		for _, runAt := range []time.Time{now, next} {
+			err := cfg.enqueueJob(ctx, job.kind, struct{}{}, runAt, recurringJobKey(job, runAt))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Method to schedule recurring jobs until the context is cancelled. Scheduling doesn't
+// depend on the previous run succeeding, so a run that is dead-lettered doesn't stop
+// later ones.
+func (cfg *apiConfig) runJobScheduler(ctx context.Context) {
+	ticker := time.NewTicker(jobScheduleInterval)
+	defer ticker.Stop()
+
+	for {
+		err := cfg.scheduleRecurringJobs(ctx)
+		if err != nil {
+			log.Printf("Error scheduling recurring jobs: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Method to delete expired tokens and old succeeded jobs
+func (cfg *apiConfig) runCleanupJob(ctx context.Context, _ struct{}) error {
+	now := time.Now().UTC()
+	refreshTokens, err := cfg.db.DeleteExpiredRefreshTokens(ctx, now)
+	if err != nil {
+		return err
+	}
+	emailTokens, err := cfg.db.DeleteExpiredEmailTokens(ctx, now)
+	if err != nil {
+		return err
+	}
+	finishedJobs, err := cfg.db.DeleteFinishedJobs(ctx, now.Add(-succeededJobsKeptFor))
+	if err != nil {
+		return err
+	}
+	if refreshTokens+emailTokens+finishedJobs > 0 {
+		log.Printf("Cleaned up %d refresh tokens, %d email tokens and %d finished jobs", refreshTokens, emailTokens, finishedJobs)
+	}
+	return nil
+}
+
+// Method to run one job worker until ctx is canceled. Workers claim due jobs one at a time
+// with FOR UPDATE SKIP LOCKED, so any number can run across server instances.
+func (cfg *apiConfig) runJobWorker(ctx context.Context) {
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Keep claiming while jobs are due so a backlog drains quickly
+		for {
+			claimed, err := cfg.runNextJob(ctx)
+			if err != nil {
+				log.Printf("Error running job: %s", err)
+			}
+			if !claimed {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Method to claim and run the next due job, recording its outcome. Failed jobs are retried
+// with backoff until their attempts run out, then dead-lettered. Reports whether a job was
+// claimed.
+func (cfg *apiConfig) runNextJob(ctx context.Context) (bool, error) {
+	job, err := cfg.db.ClaimJob(ctx, time.Now().UTC().Add(jobLease))
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// A job reclaimed after its lease ran out may have used up its attempts already
+	if job.Attempts > job.MaxAttempts {
+		return true, cfg.killJob(ctx, job, errors.New("lease expired on final attempt"))
+	}
+
+	jobCtx, cancel := context.WithTimeout(ctx, jobTimeout)
+	runErr := cfg.jobs.Run(jobCtx, job.Kind, job.Payload)
+	cancel()
+
+	// Outcome updates match on the attempt, so a worker that overran its lease can't
+	// overwrite the result of the worker that took the job over
+	if runErr == nil {
+		return true, cfg.db.CompleteJob(ctx, database.CompleteJobParams{
+			ID:       job.ID,
+			Attempts: job.Attempts,
+		})
+	}
+	if jobs.IsPermanent(runErr) || job.Attempts >= job.MaxAttempts {
+		log.Printf("Job %s (%s) is dead after %d attempts: %s", job.ID, job.Kind, job.Attempts, runErr)
+		return true, cfg.killJob(ctx, job, runErr)
+	}
+	return true, cfg.db.RetryJobLater(ctx, database.RetryJobLaterParams{
+		ID:        job.ID,
+		Attempts:  job.Attempts,
+		RunAt:     time.Now().UTC().Add(jobs.Backoff(job.Attempts)),
+		LastError: sql.NullString{String: runErr.Error(), Valid: true},
+	})
+}
+
+// Method to dead-letter a job and run its kind's dead handler, so work the job tracks
+// elsewhere is marked as given up on too. A worker that lost the job to another after
+// its lease ran out leaves it alone.
+func (cfg *apiConfig) killJob(ctx context.Context, job database.Job, cause error) error {
+	killed, err := cfg.db.KillJob(ctx, database.KillJobParams{
+		ID:        job.ID,
+		Attempts:  job.Attempts,
+		LastError: sql.NullString{String: cause.Error(), Valid: true},
+	})
+	if err != nil || killed == 0 {
+		return err
+	}
+	return cfg.jobs.Dead(ctx, job.Kind, job.Payload, cause)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"chirpy/internal/auth"
+	"chirpy/internal/database"
+	"chirpy/internal/mail"
+)
+
+// Failed logins are counted per account (by the email tried, whether or not it exists)
+// and per client IP. Accounts lock quickly; IPs get more room as they may be shared.
+var (
+	accountLoginPolicy = auth.ThrottlePolicy{
+		FreeAttempts: 3,
+		BaseDelay:    time.Second,
+		MaxDelay:     5 * time.Minute,
+		LockoutAfter: 10,
+		LockoutFor:   15 * time.Minute,
+		ResetAfter:   15 * time.Minute,
+	}
+	ipLoginPolicy = auth.ThrottlePolicy{
+		FreeAttempts: 20,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+		LockoutAfter: 100,
+		LockoutFor:   time.Hour,
+		ResetAfter:   time.Hour,
+	}
+)
+
+// Stale failure counts are pruned on this interval
+const loginThrottlePruneInterval = time.Hour
+
+// Struct for the throttle keys of one login attempt
+type loginThrottleKeys struct {
+	account string
+	ip      string
+}
+
+// Function to build the throttle keys for a login attempt
+func newLoginThrottleKeys(email, ip string) loginThrottleKeys {
+	return loginThrottleKeys{
+		account: "email:" + strings.ToLower(strings.TrimSpace(email)),
+		ip:      "ip:" + ip,
+	}
+}
+
+// Method to get how long until the attempt is allowed, the longer of the account and IP
+// waits. Zero means the attempt may go ahead.
+func (cfg *apiConfig) loginRetryAfter(ctx context.Context, keys loginThrottleKeys) (time.Duration, error) {
+	throttles, err := cfg.db.ListLoginThrottles(ctx, []string{keys.account, keys.ip})
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now().UTC()
+	var wait time.Duration
+	for _, throttle := range throttles {
+		policy := ipLoginPolicy
+		if throttle.Key == keys.account {
+			policy = accountLoginPolicy
+		}
+		wait = max(wait, policy.RetryAfter(throttle.Failures, throttle.LastFailureAt, now))
+	}
+	return wait, nil
+}
+
+// Method to respond with 429 and Retry-After while the account or IP is backing off,
+// reporting whether the attempt was refused
+func (cfg *apiConfig) refuseThrottledLogin(w http.ResponseWriter, r *http.Request, keys loginThrottleKeys) bool {
+	wait, err := cfg.loginRetryAfter(r.Context(), keys)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check login attempts", err)
+		return true
+	}
+	if wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(wait)))
+		respondWithError(w, http.StatusTooManyRequests, "Too many failed login attempts, try again later", nil)
+		return true
+	}
+	return false
+}
+
+// Method to record a failed login against the account and IP. When this failure locks an
+// existing account, its owner is emailed.
+func (cfg *apiConfig) recordLoginFailure(ctx context.Context, keys loginThrottleKeys, user *database.User) error {
+	now := time.Now().UTC()
+	_, err := cfg.db.RecordLoginFailure(ctx, database.RecordLoginFailureParams{
+		Key:         keys.ip,
+		FailedAt:    now,
+		ResetBefore: now.Add(-ipLoginPolicy.ResetAfter),
+	})
+	if err != nil {
+		return err
+	}
+	throttle, err := cfg.db.RecordLoginFailure(ctx, database.RecordLoginFailureParams{
+		Key:         keys.account,
+		FailedAt:    now,
+		ResetBefore: now.Add(-accountLoginPolicy.ResetAfter),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Send from a background job so response times don't reveal which accounts exist
+	if user != nil && throttle.Failures == accountLoginPolicy.LockoutAfter {
+		return cfg.queueEmail(ctx, lockoutMessage(user.Email))
+	}
+	return nil
+}
+
+// Function to build the email telling a user their account was locked
+func lockoutMessage(email string) mail.Message {
+	return mail.Message{
+		To:      email,
+		Subject: "Your Chirpy account has been temporarily locked",
+		Body: fmt.Sprintf("We saw too many failed login attempts on your Chirpy account, so logins are paused for %d minutes.\n\n"+
+			"If this wasn't you, someone may be trying to guess your password. Consider changing it once you can log in again.",
+			int(accountLoginPolicy.LockoutFor.Minutes())),
+	}
+}
+
+// Function to format a wait for the Retry-After header in whole seconds, rounding up
+func retryAfterSeconds(wait time.Duration) int {
+	return int(math.Ceil(wait.Seconds()))
+}
+
+// Method to delete stale failure counts
+func (cfg *apiConfig) runLoginThrottlePruneJob(ctx context.Context, _ struct{}) error {
+	resetAfter := max(accountLoginPolicy.ResetAfter, ipLoginPolicy.ResetAfter)
+	return cfg.db.DeleteLoginThrottlesBefore(ctx, time.Now().UTC().Add(-resetAfter))
+}
@@ -1,24 +1,45 @@
 package main
 
 import (
+	"chirpy/internal/auth"
 	"chirpy/internal/database"
+	"chirpy/internal/entitlements"
+	"chirpy/internal/jobs"
+	"chirpy/internal/mail"
+	"chirpy/internal/ratelimit"
+	"chirpy/internal/stream"
+	"chirpy/internal/webhook"
+	"context"
 	"database/sql"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // Struct for in-memory data
 type apiConfig struct {
 	fileserverHits atomic.Int32
-	db             *database.Queries
+	db             *database.Store
 	platform       string
-	jwtSecret      string
-	polkaKey       string
+	jwtKeys        *auth.Keyring
+	polkaSecret    string
+	plans          entitlements.Catalog
+	stream         *stream.Hub
+	mailer         mail.Mailer
+	publicURL      string
+	passwords      *auth.PasswordHashers
+	passwordPolicy auth.PasswordPolicy
+	webhooks       *webhook.Sender
+	jobs           *jobs.Registry
+	rateLimiter    *ratelimit.Limiter
 }
 
 func main() {
@@ -41,7 +62,7 @@ func main() {
 	if err != nil {
 		log.Fatalf("Error opening database: %s", err)
 	}
-	dbQueries := database.New(dbConn)
+	dbQueries := database.NewStore(dbConn)
 
 	// Get platform from environment
 	platform := os.Getenv("PLATFORM")
@@ -49,26 +70,124 @@ func main() {
 		log.Fatal("ADMIN_KEY environment variable is not set")
 	}
 
-	// Get jwtSecret from environment
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		log.Fatal("JWT_SECRET environment variable is not set")
+	// Get JWT signing keys from environment. Keys in JWT_KEYS_DIR are named <kid>.pem and
+	// JWT_ACTIVE_KID selects the one that signs new tokens. JWT_SECRET is the legacy HS256
+	// key, still accepted for tokens issued without a kid.
+	var legacyKey *auth.Key
+	if jwtSecret := os.Getenv("JWT_SECRET"); jwtSecret != "" {
+		legacyKey = auth.NewHMACKey("", jwtSecret)
+	}
+	var jwtKeys *auth.Keyring
+	if jwtKeysDir := os.Getenv("JWT_KEYS_DIR"); jwtKeysDir != "" {
+		jwtKeys, err = auth.LoadKeyringDir(jwtKeysDir, os.Getenv("JWT_ACTIVE_KID"), legacyKey)
+		if err != nil {
+			log.Fatalf("Error loading JWT keys: %s", err)
+		}
+	} else {
+		if legacyKey == nil {
+			log.Fatal("JWT_SECRET or JWT_KEYS_DIR environment variable must be set")
+		}
+		jwtKeys, err = auth.NewKeyring(legacyKey)
+		if err != nil {
+			log.Fatalf("Error creating JWT keyring: %s", err)
+		}
+	}
+
+	// Get the secret Polka signs webhooks with from environment. POLKA_KEY is still read
+	// when POLKA_WEBHOOK_SECRET is unset.
+	polkaSecret := os.Getenv("POLKA_WEBHOOK_SECRET")
+	if polkaSecret == "" {
+		polkaSecret = os.Getenv("POLKA_KEY")
+	}
+	if polkaSecret == "" {
+		log.Fatal("POLKA_WEBHOOK_SECRET environment variable is not set")
+	}
+
+	// Get the free plan's chirp edit window from environment, defaulting to 30 minutes
+	chirpEditWindow := 30 * time.Minute
+	if editWindowString := os.Getenv("CHIRP_EDIT_WINDOW"); editWindowString != "" {
+		chirpEditWindow, err = time.ParseDuration(editWindowString)
+		if err != nil {
+			log.Fatalf("CHIRP_EDIT_WINDOW is not a valid duration: %s", err)
+		}
+	}
+
+	// Get public URL used in emailed links, defaulting to the local server
+	publicURL := strings.TrimSuffix(os.Getenv("PUBLIC_URL"), "/")
+	if publicURL == "" {
+		publicURL = "http://localhost:" + port
+	}
+
+	// Choose mail delivery from environment: SMTP when SMTP_ADDR is set, .eml files when
+	// MAIL_DIR is set, otherwise the server log
+	mailFrom := os.Getenv("MAIL_FROM")
+	if mailFrom == "" {
+		mailFrom = "Chirpy <noreply@localhost>"
+	}
+	var mailer mail.Mailer = mail.LogMailer{}
+	if smtpAddr := os.Getenv("SMTP_ADDR"); smtpAddr != "" {
+		mailer = mail.SMTPMailer{
+			Addr:     smtpAddr,
+			From:     mailFrom,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+		}
+	} else if mailDir := os.Getenv("MAIL_DIR"); mailDir != "" {
+		mailer = mail.FileMailer{Dir: mailDir, From: mailFrom}
 	}
 
-	// Get Polka API key from environment
-	polkaKey := os.Getenv("POLKA_KEY")
-	if polkaKey == "" {
-		log.Fatal("POLKA_KEY environment variable is not set")
+	// Hash new passwords with Argon2id, whose cost can be raised with ARGON2_MEMORY_KIB and
+	// ARGON2_ITERATIONS. Existing bcrypt and weaker Argon2id hashes are upgraded at login.
+	argon2Hasher := auth.NewArgon2idHasher()
+	if memoryString := os.Getenv("ARGON2_MEMORY_KIB"); memoryString != "" {
+		memory, err := strconv.ParseUint(memoryString, 10, 32)
+		if err != nil {
+			log.Fatalf("ARGON2_MEMORY_KIB is not a valid number: %s", err)
+		}
+		argon2Hasher.Memory = uint32(memory)
+	}
+	if iterationsString := os.Getenv("ARGON2_ITERATIONS"); iterationsString != "" {
+		iterations, err := strconv.ParseUint(iterationsString, 10, 32)
+		if err != nil {
+			log.Fatalf("ARGON2_ITERATIONS is not a valid number: %s", err)
+		}
+		argon2Hasher.Iterations = uint32(iterations)
+	}
+	passwords := auth.NewPasswordHashers(argon2Hasher, auth.BcryptHasher{Cost: bcrypt.DefaultCost})
+
+	// Get password policy from environment: PASSWORD_MIN_LENGTH overrides the minimum
+	// length and BREACHED_PASSWORDS_FILE lists passwords to refuse, one per line
+	passwordPolicy := auth.NewPasswordPolicy()
+	if minLengthString := os.Getenv("PASSWORD_MIN_LENGTH"); minLengthString != "" {
+		passwordPolicy.MinLength, err = strconv.Atoi(minLengthString)
+		if err != nil {
+			log.Fatalf("PASSWORD_MIN_LENGTH is not a valid number: %s", err)
+		}
+	}
+	if breachedFile := os.Getenv("BREACHED_PASSWORDS_FILE"); breachedFile != "" {
+		passwordPolicy.Breached, err = auth.LoadBreachedPasswords(breachedFile)
+		if err != nil {
+			log.Fatalf("Error loading breached passwords: %s", err)
+		}
 	}
 
 	// Initialize an apiConfig struct
 	apiCfg := apiConfig{
 		fileserverHits: atomic.Int32{},
 		db:             dbQueries,
-		jwtSecret:      jwtSecret,
-		polkaKey:       polkaKey,
+		jwtKeys:        jwtKeys,
+		polkaSecret:    polkaSecret,
 		platform:       platform,
+		plans:          entitlements.DefaultCatalog(chirpEditWindow),
+		stream:         stream.NewHub(),
+		mailer:         mailer,
+		publicURL:      publicURL,
+		passwords:      passwords,
+		passwordPolicy: passwordPolicy,
+		webhooks:       webhook.NewSender(webhookSendTimeout, platform == "dev"),
+		rateLimiter:    ratelimit.New(rateLimitWindow),
 	}
+	apiCfg.jobs = apiCfg.newJobRegistry()
 
 	// Create a new http.ServeMux
 	mux := http.NewServeMux()
@@ -85,32 +204,119 @@ func main() {
 	// *** API ***
 	// Register a handler function for the /api/healthz path to display status of server
 	mux.HandleFunc("GET /api/healthz", handlerReadiness)
+	// Register a handler function for the /.well-known/jwks.json path to publish JWT verification keys
+	mux.HandleFunc("GET /.well-known/jwks.json", apiCfg.handlerJWKS)
 	// Register a handler function for the /api/polka/webhooks to handle chirpy red upgrade
 	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.handlerWebhook)
 	// Register a handler function for the /api/login path to login a user with credentials
-	mux.HandleFunc("POST /api/login", apiCfg.handlerLogin)
+	mux.Handle("POST /api/login", apiCfg.limitAnonymous(apiCfg.handlerLogin))
+	// Register a handler function for the /api/login/mfa path to finish a two-step login
+	mux.Handle("POST /api/login/mfa", apiCfg.limitAnonymous(apiCfg.handlerLoginMFA))
+	// Register handler functions for the /api/mfa/totp paths to enroll in and turn off two-factor authentication
+	mux.Handle("POST /api/mfa/totp/enroll", apiCfg.requireSession(apiCfg.handlerTOTPEnroll))
+	mux.Handle("POST /api/mfa/totp/confirm", apiCfg.requireSession(apiCfg.handlerTOTPConfirm))
+	mux.Handle("DELETE /api/mfa/totp", apiCfg.requireSession(apiCfg.handlerTOTPDisable))
 	// Register a handler function for the /api/refresh path to refresh token
-	mux.HandleFunc("POST /api/refresh", apiCfg.handlerRefresh)
+	mux.Handle("POST /api/refresh", apiCfg.limitAnonymous(apiCfg.handlerRefresh))
 	// Register a handler function for the /api/revoke path to revoke a token
-	mux.HandleFunc("POST /api/revoke", apiCfg.handlerRevoke)
+	mux.Handle("POST /api/revoke", apiCfg.limitAnonymous(apiCfg.handlerRevoke))
+	// Register handler functions for the /api/sessions paths to list and revoke sessions
+	mux.Handle("GET /api/sessions", apiCfg.requireSession(apiCfg.handlerSessionsGet))
+	mux.Handle("DELETE /api/sessions/{sessionID}", apiCfg.requireSession(apiCfg.handlerSessionsDelete))
+	mux.Handle("POST /api/sessions/revoke-all", apiCfg.requireSession(apiCfg.handlerSessionsRevokeAll))
+	// Register handler functions for the /api/tokens paths to create, list and revoke personal access tokens
+	mux.Handle("POST /api/tokens", apiCfg.requireSession(apiCfg.handlerTokensCreate))
+	mux.Handle("GET /api/tokens", apiCfg.requireSession(apiCfg.handlerTokensGet))
+	mux.Handle("DELETE /api/tokens/{tokenID}", apiCfg.requireSession(apiCfg.handlerTokensDelete))
+	// Register handler functions for the /api/webhooks paths to manage outbound webhook endpoints and their deliveries
+	mux.Handle("POST /api/webhooks", apiCfg.requireSession(apiCfg.handlerWebhookEndpointsCreate))
+	mux.Handle("GET /api/webhooks", apiCfg.requireSession(apiCfg.handlerWebhookEndpointsGet))
+	mux.Handle("DELETE /api/webhooks/{endpointID}", apiCfg.requireSession(apiCfg.handlerWebhookEndpointsDelete))
+	mux.Handle("POST /api/webhooks/{endpointID}/enable", apiCfg.requireSession(apiCfg.handlerWebhookEndpointsEnable))
+	mux.Handle("GET /api/webhooks/{endpointID}/deliveries", apiCfg.requireSession(apiCfg.handlerWebhookDeliveriesGet))
+	mux.Handle("POST /api/webhooks/{endpointID}/deliveries/{deliveryID}/redeliver", apiCfg.requireSession(apiCfg.handlerWebhookDeliveriesRedeliver))
 	// Register a handler function for the /api/users path allowing users to be created
-	mux.HandleFunc("POST /api/users", apiCfg.handlerUsersCreate)
+	mux.Handle("POST /api/users", apiCfg.limitAnonymous(apiCfg.handlerUsersCreate))
 	// Register a handler function for the /api/users path allowing users to update their emails or passwords
-	mux.HandleFunc("PUT /api/users", apiCfg.handlerUsersUpdate)
+	mux.Handle("PUT /api/users", apiCfg.requireAuth(auth.ScopeProfileWrite, apiCfg.handlerUsersUpdate))
+	// Register handler functions for the /api/users/verify-email paths to verify email addresses
+	mux.Handle("GET /api/users/verify-email", apiCfg.limitAnonymous(apiCfg.handlerVerifyEmail))
+	mux.Handle("POST /api/users/verify-email", apiCfg.limitAnonymous(apiCfg.handlerVerifyEmail))
+	mux.Handle("POST /api/users/verify-email/resend", apiCfg.requireSession(apiCfg.handlerVerifyEmailResend))
+	// Register handler functions for the /api/password-reset paths to reset a forgotten password
+	mux.Handle("POST /api/password-reset/request", apiCfg.limitAnonymous(apiCfg.handlerPasswordResetRequest))
+	mux.Handle("POST /api/password-reset/confirm", apiCfg.limitAnonymous(apiCfg.handlerPasswordResetConfirm))
+	// Register handler functions for the /api/users/{userID}/follow path to follow or unfollow a user
+	mux.Handle("POST /api/users/{userID}/follow", apiCfg.requireAuth(auth.ScopeFollowsWrite, apiCfg.handlerFollowCreate))
+	mux.Handle("DELETE /api/users/{userID}/follow", apiCfg.requireAuth(auth.ScopeFollowsWrite, apiCfg.handlerFollowDelete))
+	// Register handler functions to list a user's followers and the users they follow
+	mux.Handle("GET /api/users/{userID}/followers", apiCfg.limitAnonymous(apiCfg.handlerFollowersGet))
+	mux.Handle("GET /api/users/{userID}/following", apiCfg.limitAnonymous(apiCfg.handlerFollowingGet))
+	// Register a handler function for the /api/timeline path to retreive chirps from followed users
+	mux.Handle("GET /api/timeline", apiCfg.requireAuth(auth.ScopeChirpsRead, apiCfg.handlerTimeline))
+	// Register a handler function for the /api/search path to search chirps and users
+	mux.Handle("GET /api/search", apiCfg.optionalAuth(auth.ScopeChirpsRead, apiCfg.handlerSearch))
+	// Register a handler function for the /api/stream path to push chirp events over Server-Sent Events
+	mux.Handle("GET /api/stream", apiCfg.optionalStreamAuth(auth.ScopeChirpsRead, apiCfg.handlerStream))
+	// Register handler functions for the /api/notifications paths to retreive notifications and mark them read
+	mux.Handle("GET /api/notifications", apiCfg.requireAuth(auth.ScopeNotificationsRead, apiCfg.handlerNotificationsGet))
+	mux.Handle("POST /api/notifications/read", apiCfg.requireAuth(auth.ScopeNotificationsWrite, apiCfg.handlerNotificationsRead))
+	mux.Handle("GET /api/notifications/unread_count", apiCfg.requireAuth(auth.ScopeNotificationsRead, apiCfg.handlerNotificationsUnreadCount))
+	// Register a handler function for the /api/tags/{tag}/chirps path to retreive chirps using a hashtag
+	mux.Handle("GET /api/tags/{tag}/chirps", apiCfg.optionalAuth(auth.ScopeChirpsRead, apiCfg.handlerTagChirps))
+	// Register a handler function for the /api/tags/trending path to retreive trending hashtags
+	mux.Handle("GET /api/tags/trending", apiCfg.limitAnonymous(apiCfg.handlerTagsTrending))
 	// Register a handler function for the /api/chirps path to create chirps
-	mux.HandleFunc("POST /api/chirps", apiCfg.handlerChirpsCreate)
+	mux.Handle("POST /api/chirps", apiCfg.requireAuth(auth.ScopeChirpsWrite, apiCfg.handlerChirpsCreate))
 	// Register a handler function for the /api/chirps path to retreive all chirps
-	mux.HandleFunc("GET /api/chirps", apiCfg.handlerChirpsRetrieve)
+	mux.Handle("GET /api/chirps", apiCfg.optionalAuth(auth.ScopeChirpsRead, apiCfg.handlerChirpsRetrieve))
 	// Register a handler function for the /api/chirps path to retreive one specified chirp
-	mux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.handlerChirpsGet)
+	mux.Handle("GET /api/chirps/{chirpID}", apiCfg.optionalAuth(auth.ScopeChirpsRead, apiCfg.handlerChirpsGet))
+	// Register a handler function for the /api/chirps/{chirpID}/thread path to retreive a chirp's conversation
+	mux.Handle("GET /api/chirps/{chirpID}/thread", apiCfg.optionalAuth(auth.ScopeChirpsRead, apiCfg.handlerChirpsThread))
 	// Register a handler function for the /api/chirps/ path to delete a specific chirp
-	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.handlerChirpsDelete)
+	mux.Handle("DELETE /api/chirps/{chirpID}", apiCfg.requireAuth(auth.ScopeChirpsWrite, apiCfg.handlerChirpsDelete))
+	// Register a handler function for the /api/chirps/ path to edit a specific chirp
+	mux.Handle("PATCH /api/chirps/{chirpID}", apiCfg.requireAuth(auth.ScopeChirpsWrite, apiCfg.handlerChirpsUpdate))
+	// Register a handler function for the /api/chirps/{chirpID}/history path to retreive a chirp's edit history
+	mux.Handle("GET /api/chirps/{chirpID}/history", apiCfg.optionalAuth(auth.ScopeChirpsRead, apiCfg.handlerChirpsHistory))
+	// Register handler functions for the /api/chirps/{chirpID}/like path to like or unlike a chirp
+	mux.Handle("POST /api/chirps/{chirpID}/like", apiCfg.requireAuth(auth.ScopeChirpsWrite, apiCfg.handlerChirpsLike))
+	mux.Handle("DELETE /api/chirps/{chirpID}/like", apiCfg.requireAuth(auth.ScopeChirpsWrite, apiCfg.handlerChirpsUnlike))
+	// Register handler functions for the /api/chirps/{chirpID}/rechirp path to rechirp or undo a rechirp
+	mux.Handle("POST /api/chirps/{chirpID}/rechirp", apiCfg.requireAuth(auth.ScopeChirpsWrite, apiCfg.handlerChirpsRechirp))
+	mux.Handle("DELETE /api/chirps/{chirpID}/rechirp", apiCfg.requireAuth(auth.ScopeChirpsWrite, apiCfg.handlerChirpsUndoRechirp))
 
 	// *** ADMIN ***
 	// Register a handler function for the /admin/reset path to reset hit count
-	mux.HandleFunc("POST /admin/reset", apiCfg.handlerReset)
+	mux.Handle("POST /admin/reset", apiCfg.requireRole(database.RoleAdmin, apiCfg.handlerReset))
 	// Register a handler function for the /admin/metrics path to display hit count
-	mux.HandleFunc("GET /admin/metrics", apiCfg.handlerMetrics)
+	mux.Handle("GET /admin/metrics", apiCfg.requireRole(database.RoleAdmin, apiCfg.handlerMetrics))
+	// Register admin handler functions to find and moderate users
+	mux.Handle("GET /admin/users", apiCfg.requireRole(database.RoleAdmin, apiCfg.handlerAdminUsersGet))
+	mux.Handle("GET /admin/users/{userID}", apiCfg.requireRole(database.RoleAdmin, apiCfg.handlerAdminUserGet))
+	mux.Handle("POST /admin/users/{userID}/suspend", apiCfg.requireRole(database.RoleAdmin, apiCfg.handlerAdminUserSuspend))
+	mux.Handle("POST /admin/users/{userID}/unsuspend", apiCfg.requireRole(database.RoleAdmin, apiCfg.handlerAdminUserUnsuspend))
+	mux.Handle("POST /admin/users/{userID}/force-password-reset", apiCfg.requireRole(database.RoleAdmin, apiCfg.handlerAdminUserForcePasswordReset))
+	mux.Handle("PUT /admin/users/{userID}/role", apiCfg.requireRole(database.RoleAdmin, apiCfg.handlerAdminUserRole))
+	// Register a handler function for moderators to delete any chirp
+	mux.Handle("DELETE /admin/chirps/{chirpID}", apiCfg.requireRole(database.RoleModerator, apiCfg.handlerAdminChirpsDelete))
+	// Register admin handler functions to inspect background jobs and retry dead ones
+	mux.Handle("GET /admin/jobs", apiCfg.requireRole(database.RoleAdmin, apiCfg.handlerAdminJobsGet))
+	mux.Handle("GET /admin/jobs/{jobID}", apiCfg.requireRole(database.RoleAdmin, apiCfg.handlerAdminJobGet))
+	mux.Handle("POST /admin/jobs/{jobID}/retry", apiCfg.requireRole(database.RoleAdmin, apiCfg.handlerAdminJobRetry))
+	// Register a handler function to page through the audit log of admin actions
+	mux.Handle("GET /admin/audit-log", apiCfg.requireRole(database.RoleAdmin, apiCfg.handlerAdminAuditLog))
+
+	// Start background workers to run queued jobs, and one to schedule recurring jobs such
+	// as cleanup, trending tags and subscription expiry
+	for range jobWorkers {
+		go apiCfg.runJobWorker(context.Background())
+	}
+	go apiCfg.runJobScheduler(context.Background())
+
+	// Start listener to relay chirp events from every server instance to stream clients
+	go apiCfg.runStreamListener(context.Background(), dbURL)
 
 	// Create a new HTTP server struct
 	srv := &http.Server{
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"chirpy/internal/auth"
+	"chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+type TokenType string
+
+// TokenTypeAccess, TokenTypePersonal
+const (
+	// Short-lived access JWT from a login, carrying every scope
+	TokenTypeAccess TokenType = "access"
+	// Long-lived personal access token limited to its granted scopes
+	TokenTypePersonal TokenType = "personal"
+)
+
+// Struct for the authenticated identity behind a request
+type Principal struct {
+	UserID      uuid.UUID
+	TokenType   TokenType
+	Scopes      []string
+	IsChirpyRed bool
+	Role        string
+}
+
+// Method to check whether the principal may use an endpoint requiring a scope
+func (p Principal) HasScope(scope auth.Scope) bool {
+	return p.TokenType == TokenTypeAccess || auth.HasScope(p.Scopes, scope)
+}
+
+// Ranks of user roles, each including the permissions of those below it
+var roleRanks = map[string]int{
+	database.RoleUser:      0,
+	database.RoleModerator: 1,
+	database.RoleAdmin:     2,
+}
+
+// Method to check whether the principal has a role at least as privileged as the given one
+func (p Principal) HasRole(role string) bool {
+	rank, ok := roleRanks[p.Role]
+	return ok && rank >= roleRanks[role]
+}
+
+// errAccountSuspended is returned when a valid token belongs to a suspended user
+var errAccountSuspended = errors.New("account is suspended")
+
+// Context key for the request's principal
+type principalContextKey struct{}
+
+// Function to get the principal placed in the request context by the auth middleware.
+// Only handlers behind requireAuth or requireSession can rely on it being present.
+func principalFrom(r *http.Request) Principal {
+	principal, _ := r.Context().Value(principalContextKey{}).(Principal)
+	return principal
+}
+
+// Function to get the viewing user for handlers behind optionalAuth. Anonymous requests
+// return an invalid NullUUID.
+func viewerIDFrom(r *http.Request) uuid.NullUUID {
+	principal, ok := r.Context().Value(principalContextKey{}).(Principal)
+	if !ok {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: principal.UserID, Valid: true}
+}
+
+// Method to validate a bearer token that is either an access JWT or a personal access
+// token, and gather the principal it identifies
+func (cfg *apiConfig) authenticate(ctx context.Context, token string) (Principal, error) {
+	if auth.IsPersonalToken(token) {
+		apiToken, err := cfg.db.GetAPITokenByHash(ctx, auth.HashPersonalToken(token))
+		if err != nil {
+			return Principal{}, errors.New("invalid personal access token")
+		}
+		user, err := cfg.db.GetUser(ctx, apiToken.UserID)
+		if err != nil {
+			return Principal{}, err
+		}
+		if user.SuspendedAt.Valid {
+			return Principal{}, errAccountSuspended
+		}
+		err = cfg.db.TouchAPIToken(ctx, apiToken.ID)
+		if err != nil {
+			return Principal{}, err
+		}
+		return Principal{
+			UserID:      user.ID,
+			TokenType:   TokenTypePersonal,
+			Scopes:      apiToken.Scopes,
+			IsChirpyRed: user.IsChirpyRed,
+			Role:        user.Role,
+		}, nil
+	}
+
+	claims, err := auth.ParseAccessToken(token, cfg.jwtKeys)
+	if err != nil {
+		return Principal{}, err
+	}
+	user, err := cfg.db.GetUser(ctx, claims.UserID)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	// Reject tokens issued before the user last logged out everywhere
+	if user.TokensValidAfter.Valid && claims.RevokedBy(user.TokensValidAfter.Time) {
+		return Principal{}, errors.New("token has been revoked")
+	}
+	if user.SuspendedAt.Valid {
+		return Principal{}, errAccountSuspended
+	}
+	return Principal{
+		UserID:      user.ID,
+		TokenType:   TokenTypeAccess,
+		IsChirpyRed: user.IsChirpyRed,
+		Role:        user.Role,
+	}, nil
+}
+
+// Middleware method to require a bearer token with the given scope. Access JWTs carry
+// every scope.
+func (cfg *apiConfig) requireAuth(scope auth.Scope, next http.HandlerFunc) http.Handler {
+	return cfg.middlewareAuth(false, true, func(p Principal) bool { return p.HasScope(scope) }, string(scope), next)
+}
+
+// Middleware method to require an access JWT from a login. Used for managing sessions,
+// tokens and two-factor settings, which personal access tokens can never do.
+func (cfg *apiConfig) requireSession(next http.HandlerFunc) http.Handler {
+	return cfg.middlewareAuth(false, true, func(p Principal) bool { return p.TokenType == TokenTypeAccess }, "", next)
+}
+
+// Middleware method to require an access JWT from a user with at least the given role.
+// Personal access tokens can never use admin endpoints, which aren't rate limited.
+func (cfg *apiConfig) requireRole(role string, next http.HandlerFunc) http.Handler {
+	return cfg.middlewareAuth(false, false, func(p Principal) bool {
+		return p.TokenType == TokenTypeAccess && p.HasRole(role)
+	}, "", next)
+}
+
+// Middleware method to identify the viewer when a bearer token is sent, letting anonymous
+// requests through. A token that is sent must still be valid and have the scope.
+func (cfg *apiConfig) optionalAuth(scope auth.Scope, next http.HandlerFunc) http.Handler {
+	return cfg.middlewareAuth(true, true, func(p Principal) bool { return p.HasScope(scope) }, string(scope), next)
+}
+
+// Middleware method like optionalAuth for long-lived streams, which aren't rate limited
+// since each connection is a single request held open
+func (cfg *apiConfig) optionalStreamAuth(scope auth.Scope, next http.HandlerFunc) http.Handler {
+	return cfg.middlewareAuth(true, false, func(p Principal) bool { return p.HasScope(scope) }, string(scope), next)
+}
+
+// Middleware method to authenticate a request once and place its principal in the context.
+// Metered requests count against the user's plan rate limit, or the client IP's when anonymous.
+func (cfg *apiConfig) middlewareAuth(optional, metered bool, allowed func(Principal) bool, scope string, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		// Gather bearer token (JWT or personal access token)
+		token, err := auth.GetBearerToken(r.Header)
+		if optional && errors.Is(err, auth.ErrNoAuthHeaderIncluded) {
+			if metered && cfg.refuseAnonymousRateLimited(w, r) {
+				return
+			}
+			next(w, r)
+			return
+		}
+		if err != nil {
+			respondWithAuthError(w, http.StatusUnauthorized, "", scope, "Couldn't find token", err)
+			return
+		}
+
+		// Validate token to generate principal
+		principal, err := cfg.authenticate(r.Context(), token)
+		if errors.Is(err, errAccountSuspended) {
+			respondWithAuthError(w, http.StatusForbidden, "", scope, "Account is suspended", err)
+			return
+		}
+		if err != nil {
+			respondWithAuthError(w, http.StatusUnauthorized, "invalid_token", scope, "Couldn't validate token", err)
+			return
+		}
+		if !allowed(principal) {
+			msg := "Token lacks required scope"
+			if scope == "" {
+				msg = "Token can't be used for this endpoint"
+			}
+			respondWithAuthError(w, http.StatusForbidden, "insufficient_scope", scope, msg, nil)
+			return
+		}
+
+		// Count the request against the user's plan rate limit
+		if metered && cfg.refuseUserRateLimited(w, principal) {
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), principalContextKey{}, principal)
+		next(w, r.WithContext(ctx))
+	})
+}
+
+// Function to respond to a failed authentication with a WWW-Authenticate challenge (RFC 6750)
+func respondWithAuthError(w http.ResponseWriter, code int, errorCode, scope, msg string, err error) {
+	challenge := `Bearer realm="chirpy"`
+	if errorCode != "" {
+		challenge += fmt.Sprintf(`, error="%s"`, errorCode)
+	}
+	if errorCode == "insufficient_scope" && scope != "" {
+		challenge += fmt.Sprintf(`, scope="%s"`, scope)
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	respondWithError(w, code, msg, err)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Page size limits for paginated endpoints
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// Struct for the position of the last item on a page (keyset pagination). Rank is only
+// set for pages ordered by search relevance, and Path for pages in thread tree order.
+type pageCursor struct {
+	Rank      float64   `json:"r,omitempty"`
+	Path      string    `json:"p,omitempty"`
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+}
+
+// Struct for limit and cursor query parameters of a paginated request
+type pageParams struct {
+	Limit  int32
+	Cursor *pageCursor
+}
+
+// Function to gather and validate limit and cursor query parameters
+func parsePageParams(r *http.Request) (pageParams, error) {
+
+	params := pageParams{
+		Limit: defaultPageLimit,
+	}
+
+	// Validate limit is a positive number and clamp it to the maximum page size
+	limitString := r.URL.Query().Get("limit")
+	if limitString != "" {
+		limit, err := strconv.Atoi(limitString)
+		if err != nil || limit < 1 {
+			return pageParams{}, errors.New("Invalid limit")
+		}
+		if limit > maxPageLimit {
+			limit = maxPageLimit
+		}
+		params.Limit = int32(limit)
+	}
+
+	// Decode cursor if provided
+	cursorString := r.URL.Query().Get("cursor")
+	if cursorString != "" {
+		cursor, err := decodeCursor(cursorString)
+		if err != nil {
+			return pageParams{}, errors.New("Invalid cursor")
+		}
+		params.Cursor = &cursor
+	}
+
+	return params, nil
+}
+
+// Function to encode a cursor as an opaque URL-safe string
+func encodeCursor(createdAt time.Time, id uuid.UUID) string {
+	dat, _ := json.Marshal(pageCursor{
+		CreatedAt: createdAt,
+		ID:        id,
+	})
+	return base64.RawURLEncoding.EncodeToString(dat)
+}
+
+// Function to encode a cursor for a page ordered by search rank
+func encodeRankedCursor(rank float64, createdAt time.Time, id uuid.UUID) string {
+	dat, _ := json.Marshal(pageCursor{
+		Rank:      rank,
+		CreatedAt: createdAt,
+		ID:        id,
+	})
+	return base64.RawURLEncoding.EncodeToString(dat)
+}
+
+// Function to encode a cursor for a page in thread tree order. The item's path is kept in
+// the cursor so the next page still starts in the right place if the item is deleted.
+func encodeTreeCursor(path string, createdAt time.Time, id uuid.UUID) string {
+	dat, _ := json.Marshal(pageCursor{
+		Path:      path,
+		CreatedAt: createdAt,
+		ID:        id,
+	})
+	return base64.RawURLEncoding.EncodeToString(dat)
+}
+
+// Function to decode an opaque cursor string
+func decodeCursor(s string) (pageCursor, error) {
+	dat, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return pageCursor{}, err
+	}
+	cursor := pageCursor{}
+	err = json.Unmarshal(dat, &cursor)
+	if err != nil {
+		return pageCursor{}, err
+	}
+	if cursor.ID == uuid.Nil || cursor.CreatedAt.IsZero() {
+		return pageCursor{}, errors.New("incomplete cursor")
+	}
+	return cursor, nil
+}
+
+// Method to get cursor search rank as a nullable query argument
+func (p pageParams) afterRank() sql.NullFloat64 {
+	if p.Cursor == nil {
+		return sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: p.Cursor.Rank, Valid: true}
+}
+
+// Method to get cursor tree path as a nullable query argument
+func (p pageParams) afterPath() sql.NullString {
+	if p.Cursor == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: p.Cursor.Path, Valid: true}
+}
+
+// Method to get cursor timestamp as a nullable query argument
+func (p pageParams) afterCreatedAt() sql.NullTime {
+	if p.Cursor == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: p.Cursor.CreatedAt, Valid: true}
+}
+
+// Method to get cursor ID as a nullable query argument
+func (p pageParams) afterID() uuid.NullUUID {
+	if p.Cursor == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: p.Cursor.ID, Valid: true}
+}
+
+// Method to get the number of rows to fetch (one extra to detect a following page)
+func (p pageParams) fetchSize() int32 {
+	return p.Limit + 1
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"chirpy/internal/entitlements"
+)
+
+// Requests are counted in fixed one minute windows in memory, so each server instance
+// enforces the limit on the traffic it receives
+const rateLimitWindow = time.Minute
+
+// Method to count a request against a key's per-minute limit and respond with 429 and
+// Retry-After once the limit for the current window is used up, reporting whether the
+// request was refused
+func (cfg *apiConfig) refuseRateLimited(w http.ResponseWriter, key string, limit int) bool {
+	now := time.Now()
+	result := cfg.rateLimiter.Allow(key, limit, now)
+
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	if !result.Allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(result.ResetAt.Sub(now))))
+		respondWithError(w, http.StatusTooManyRequests, "Rate limit exceeded, try again later", nil)
+		return true
+	}
+	return false
+}
+
+// Method to count an authenticated request against the principal's plan limit. The plan
+// comes from the principal's Chirpy Red flag, so no extra query is needed per request.
+func (cfg *apiConfig) refuseUserRateLimited(w http.ResponseWriter, principal Principal) bool {
+	plan := entitlements.PlanFree
+	if principal.IsChirpyRed {
+		plan = entitlements.PlanRed
+	}
+	return cfg.refuseRateLimited(w, "user:"+principal.UserID.String(), cfg.plans[plan].RequestsPerMinute)
+}
+
+// Method to count an anonymous request against the free plan's limit for the client IP
+func (cfg *apiConfig) refuseAnonymousRateLimited(w http.ResponseWriter, r *http.Request) bool {
+	return cfg.refuseRateLimited(w, "ip:"+clientIP(r), cfg.plans[entitlements.PlanFree].RequestsPerMinute)
+}
+
+// Middleware method to rate limit public endpoints that take no bearer token by client IP
+func (cfg *apiConfig) limitAnonymous(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cfg.refuseAnonymousRateLimited(w, r) {
+			return
+		}
+		next(w, r)
+	})
+}
@@ -1,6 +1,9 @@
 package main
 
-import "net/http"
+import (
+	"log"
+	"net/http"
+)
 
 // Handler method for apiConfig struct to reset hit count and users
 func (cfg *apiConfig) handlerReset(w http.ResponseWriter, r *http.Request) {
@@ -15,13 +18,25 @@ func (cfg *apiConfig) handlerReset(w http.ResponseWriter, r *http.Request) {
 	// Reset hit count to 0
 	cfg.fileserverHits.Store(0)
 
+	// Gather admin's email for the audit log, as the reset deletes their account
+	admin, err := cfg.db.GetUser(r.Context(), principalFrom(r).UserID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("Failed to get admin user: " + err.Error()))
+		return
+	}
+
 	// Reset database
-	err := cfg.db.Reset(r.Context())
+	err = cfg.db.Reset(r.Context())
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte("Failed to reset the database: " + err.Error()))
 		return
 	}
+	err = cfg.db.AuditDatabaseReset(r.Context(), admin.Email)
+	if err != nil {
+		log.Printf("Error writing audit log for reset: %s", err)
+	}
 
 	// Set Status OK and Respond with statement confirming database reset
 	w.WriteHeader(http.StatusOK)
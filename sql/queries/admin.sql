@@ -0,0 +1,49 @@
+-- name: AdminListUsers :many
+SELECT * FROM users
+WHERE (sqlc.narg('pattern')::text IS NULL
+    OR lower(email) LIKE sqlc.narg('pattern')::text
+    OR handle LIKE sqlc.narg('pattern')::text)
+AND (sqlc.narg('role')::text IS NULL OR role = sqlc.narg('role')::text)
+AND (sqlc.narg('suspended')::boolean IS NULL OR (suspended_at IS NOT NULL) = sqlc.narg('suspended')::boolean)
+AND (sqlc.narg('after_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: SetUserRole :one
+UPDATE users SET role = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetUserSuspended :one
+UPDATE users SET suspended_at = CASE WHEN sqlc.arg('suspended')::boolean THEN NOW() ELSE NULL END,
+updated_at = NOW()
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: SetPasswordResetRequired :one
+UPDATE users SET password_reset_required = true, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateAuditLogEntry :exec
+INSERT INTO audit_log (id, created_at, actor_id, action, target_type, target_id, details)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+);
+
+-- name: ListAuditLog :many
+SELECT * FROM audit_log
+WHERE (sqlc.narg('actor_id')::uuid IS NULL OR actor_id = sqlc.narg('actor_id')::uuid)
+AND (sqlc.narg('target_id')::uuid IS NULL OR target_id = sqlc.narg('target_id')::uuid)
+AND (sqlc.narg('action')::text IS NULL OR action = sqlc.narg('action')::text)
+AND (sqlc.narg('after_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_size');
\ No newline at end of file
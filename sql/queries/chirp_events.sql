@@ -0,0 +1,30 @@
+-- name: CreateChirpEvent :one
+INSERT INTO chirp_events (created_at, type, chirp_id, user_id)
+VALUES (
+    NOW(),
+    $1,
+    $2,
+    $3
+)
+RETURNING id;
+
+-- name: NotifyChirpEvent :exec
+SELECT pg_notify('chirp_events', sqlc.arg('payload')::text);
+
+-- name: GetChirpEvent :one
+SELECT * FROM chirp_events
+WHERE id = $1;
+
+-- name: GetLatestChirpEventID :one
+SELECT COALESCE(MAX(id), 0)::bigint AS id
+FROM chirp_events;
+
+-- name: ListChirpEventsAfter :many
+SELECT * FROM chirp_events
+WHERE id > sqlc.arg('after_id')
+ORDER BY id
+LIMIT sqlc.arg('page_size');
+
+-- name: DeleteChirpEventsBefore :exec
+DELETE FROM chirp_events
+WHERE created_at < $1;
\ No newline at end of file
@@ -1,22 +1,159 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3
 )
 RETURNING *;
 
--- name: GetChirps :many
+-- name: ListChirpsAsc :many
 SELECT * FROM chirps
-ORDER BY created_at ASC;
+WHERE deleted_at IS NULL
+AND (sqlc.narg('after_created_at')::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('page_size');
+
+-- name: ListAuthorFeedAsc :many
+WITH feed AS (
+    SELECT c.id AS chirp_id, c.created_at AS activity_at, NULL::uuid AS rechirped_by
+    FROM chirps c
+    WHERE c.user_id = sqlc.arg('author_id')
+    UNION ALL
+    SELECT r.chirp_id, r.created_at, r.user_id
+    FROM rechirps r
+    WHERE r.user_id = sqlc.arg('author_id')
+)
+SELECT sqlc.embed(chirps), feed.activity_at::timestamp AS activity_at, feed.rechirped_by::uuid AS rechirped_by FROM feed
+JOIN chirps ON chirps.id = feed.chirp_id
+WHERE chirps.deleted_at IS NULL
+AND (sqlc.narg('after_created_at')::timestamp IS NULL
+    OR (feed.activity_at, chirps.id) > (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::uuid))
+ORDER BY feed.activity_at ASC, chirps.id ASC
+LIMIT sqlc.arg('page_size');
+
+-- name: ListChirpsDesc :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+AND (sqlc.narg('after_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: ListAuthorFeedDesc :many
+WITH feed AS (
+    SELECT c.id AS chirp_id, c.created_at AS activity_at, NULL::uuid AS rechirped_by
+    FROM chirps c
+    WHERE c.user_id = sqlc.arg('author_id')
+    UNION ALL
+    SELECT r.chirp_id, r.created_at, r.user_id
+    FROM rechirps r
+    WHERE r.user_id = sqlc.arg('author_id')
+)
+SELECT sqlc.embed(chirps), feed.activity_at::timestamp AS activity_at, feed.rechirped_by::uuid AS rechirped_by FROM feed
+JOIN chirps ON chirps.id = feed.chirp_id
+WHERE chirps.deleted_at IS NULL
+AND (sqlc.narg('after_created_at')::timestamp IS NULL
+    OR (feed.activity_at, chirps.id) < (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::uuid))
+ORDER BY feed.activity_at DESC, chirps.id DESC
+LIMIT sqlc.arg('page_size');
 
 -- name: GetChirp :one
 SELECT * FROM chirps
 WHERE id = $1;
 
+-- name: LockChirp :one
+SELECT * FROM chirps
+WHERE id = $1
+FOR UPDATE;
+
 -- name: DeleteChirp :exec
 DELETE FROM chirps
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: UpdateChirpBody :one
+UPDATE chirps SET body = $2, edited_at = NOW(), updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: TombstoneChirp :exec
+UPDATE chirps SET body = '', deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1;
+
+-- name: ChirpHasReplies :one
+SELECT EXISTS (
+    SELECT 1 FROM chirps
+    WHERE in_reply_to = sqlc.arg('chirp_id')::uuid
+)::boolean AS has_replies;
+
+-- name: IncrementReplyCount :one
+UPDATE chirps SET reply_count = reply_count + 1
+WHERE id = $1
+AND deleted_at IS NULL
+RETURNING user_id;
+
+-- name: DecrementReplyCount :exec
+UPDATE chirps SET reply_count = GREATEST(reply_count - 1, 0)
+WHERE id = $1;
+
+-- name: ListChirpAncestors :many
+WITH RECURSIVE ancestors(id, in_reply_to, depth) AS (
+    SELECT c.id, c.in_reply_to, 1
+    FROM chirps c
+    WHERE c.id = (SELECT p.in_reply_to FROM chirps p WHERE p.id = sqlc.arg('chirp_id'))
+    UNION ALL
+    SELECT c.id, c.in_reply_to, a.depth + 1
+    FROM chirps c
+    JOIN ancestors a ON c.id = a.in_reply_to
+)
+SELECT chirps.* FROM chirps
+JOIN ancestors ON ancestors.id = chirps.id
+ORDER BY ancestors.depth DESC;
+
+-- name: ListChirpDescendants :many
+WITH RECURSIVE descendants(id, depth, sort_key) AS (
+    SELECT c.id, 1, to_char(c.created_at, 'YYYYMMDDHH24MISSUS') || c.id::text
+    FROM chirps c
+    WHERE c.in_reply_to = sqlc.arg('chirp_id')::uuid
+    UNION ALL
+    SELECT c.id, d.depth + 1, d.sort_key || '/' || to_char(c.created_at, 'YYYYMMDDHH24MISSUS') || c.id::text
+    FROM chirps c
+    JOIN descendants d ON c.in_reply_to = d.id
+)
+SELECT sqlc.embed(chirps), descendants.depth::integer AS depth, descendants.sort_key::text AS sort_key FROM chirps
+JOIN descendants ON descendants.id = chirps.id
+WHERE sqlc.narg('after_sort_key')::text IS NULL
+OR descendants.sort_key > sqlc.narg('after_sort_key')::text
+ORDER BY descendants.sort_key
+LIMIT sqlc.arg('page_size');
+
+-- name: ListChirpsByIDs :many
+SELECT * FROM chirps
+WHERE id = ANY(sqlc.arg('ids')::uuid[]);
+
+-- name: RecomputeChirpCounters :execrows
+UPDATE chirps SET like_count = counts.like_count,
+rechirp_count = counts.rechirp_count,
+reply_count = counts.reply_count
+FROM (
+    SELECT c.id,
+        COALESCE(l.n, 0)::integer AS like_count,
+        COALESCE(rc.n, 0)::integer AS rechirp_count,
+        COALESCE(rp.n, 0)::integer AS reply_count
+    FROM chirps c
+    LEFT JOIN (SELECT chirp_id, COUNT(*) AS n FROM likes GROUP BY chirp_id) l ON l.chirp_id = c.id
+    LEFT JOIN (SELECT chirp_id, COUNT(*) AS n FROM rechirps GROUP BY chirp_id) rc ON rc.chirp_id = c.id
+    LEFT JOIN (
+        SELECT in_reply_to, COUNT(*) AS n FROM chirps
+        WHERE in_reply_to IS NOT NULL
+        AND deleted_at IS NULL
+        GROUP BY in_reply_to
+    ) rp ON rp.in_reply_to = c.id
+) counts
+WHERE chirps.id = counts.id
+AND (chirps.like_count, chirps.rechirp_count, chirps.reply_count)
+    IS DISTINCT FROM (counts.like_count, counts.rechirp_count, counts.reply_count);
\ No newline at end of file
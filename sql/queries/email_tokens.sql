@@ -0,0 +1,27 @@
+-- name: CreateEmailToken :exec
+INSERT INTO email_tokens (token_hash, created_at, user_id, purpose, email, expires_at)
+VALUES (
+    $1,
+    NOW(),
+    $2,
+    $3,
+    $4,
+    $5
+);
+
+-- name: UseEmailToken :one
+UPDATE email_tokens SET used_at = NOW()
+WHERE token_hash = $1
+AND purpose = $2
+AND used_at IS NULL
+AND expires_at > NOW()
+RETURNING *;
+
+-- name: DeleteEmailTokens :exec
+DELETE FROM email_tokens
+WHERE user_id = $1
+AND purpose = $2;
+
+-- name: DeleteExpiredEmailTokens :execrows
+DELETE FROM email_tokens
+WHERE expires_at < $1;
\ No newline at end of file
@@ -0,0 +1,56 @@
+-- name: CreateFollow :execrows
+INSERT INTO follows (follower_id, followee_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT (follower_id, followee_id) DO NOTHING;
+
+-- name: DeleteFollow :exec
+DELETE FROM follows
+WHERE follower_id = $1
+AND followee_id = $2;
+
+-- name: GetFollowCounts :one
+SELECT
+    (SELECT COUNT(*) FROM follows f WHERE f.followee_id = sqlc.arg('user_id'))::bigint AS follower_count,
+    (SELECT COUNT(*) FROM follows f WHERE f.follower_id = sqlc.arg('user_id'))::bigint AS following_count;
+
+-- name: ListFollowers :many
+SELECT users.*, follows.created_at AS followed_at,
+    (SELECT COUNT(*) FROM follows f WHERE f.followee_id = users.id)::bigint AS follower_count,
+    (SELECT COUNT(*) FROM follows f WHERE f.follower_id = users.id)::bigint AS following_count
+FROM follows
+JOIN users ON users.id = follows.follower_id
+WHERE follows.followee_id = sqlc.arg('user_id')
+AND (sqlc.narg('after_created_at')::timestamp IS NULL
+    OR (follows.created_at, users.id) < (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::uuid))
+ORDER BY follows.created_at DESC, users.id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: ListFollowing :many
+SELECT users.*, follows.created_at AS followed_at,
+    (SELECT COUNT(*) FROM follows f WHERE f.followee_id = users.id)::bigint AS follower_count,
+    (SELECT COUNT(*) FROM follows f WHERE f.follower_id = users.id)::bigint AS following_count
+FROM follows
+JOIN users ON users.id = follows.followee_id
+WHERE follows.follower_id = sqlc.arg('user_id')
+AND (sqlc.narg('after_created_at')::timestamp IS NULL
+    OR (follows.created_at, users.id) < (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::uuid))
+ORDER BY follows.created_at DESC, users.id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: ListTimelineChirps :many
+SELECT chirps.* FROM chirps
+JOIN follows ON follows.followee_id = chirps.user_id
+WHERE follows.follower_id = sqlc.arg('user_id')
+AND chirps.deleted_at IS NULL
+AND (sqlc.narg('after_created_at')::timestamp IS NULL
+    OR (chirps.created_at, chirps.id) < (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::uuid))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: ListFollowingIDs :many
+SELECT followee_id FROM follows
+WHERE follower_id = $1;
\ No newline at end of file
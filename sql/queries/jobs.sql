@@ -0,0 +1,74 @@
+-- name: EnqueueJob :exec
+INSERT INTO jobs (id, created_at, updated_at, kind, payload, unique_key, status, max_attempts, run_at)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    'pending',
+    $4,
+    $5
+)
+ON CONFLICT (unique_key) DO NOTHING;
+
+-- name: ClaimJob :one
+UPDATE jobs SET status = 'running', attempts = attempts + 1,
+locked_until = sqlc.arg('lease_until')::timestamp,
+updated_at = NOW()
+WHERE jobs.id = (
+    SELECT j.id FROM jobs j
+    WHERE (j.status = 'pending' AND j.run_at <= NOW())
+    OR (j.status = 'running' AND j.locked_until < NOW())
+    ORDER BY j.run_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING jobs.*;
+
+-- name: CompleteJob :exec
+UPDATE jobs SET status = 'succeeded', locked_until = NULL, last_error = NULL,
+finished_at = NOW(), updated_at = NOW()
+WHERE id = $1
+AND attempts = $2
+AND status = 'running';
+
+-- name: RetryJobLater :exec
+UPDATE jobs SET status = 'pending', locked_until = NULL, run_at = $3, last_error = $4,
+updated_at = NOW()
+WHERE id = $1
+AND attempts = $2
+AND status = 'running';
+
+-- name: KillJob :execrows
+UPDATE jobs SET status = 'dead', locked_until = NULL, last_error = $3,
+finished_at = NOW(), updated_at = NOW()
+WHERE id = $1
+AND attempts = $2
+AND status = 'running';
+
+-- name: GetJob :one
+SELECT * FROM jobs
+WHERE id = $1;
+
+-- name: ListJobs :many
+SELECT * FROM jobs
+WHERE (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status')::text)
+AND (sqlc.narg('kind')::text IS NULL OR kind = sqlc.narg('kind')::text)
+AND (sqlc.narg('after_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: RequeueDeadJob :one
+UPDATE jobs SET status = 'pending', attempts = 0, run_at = NOW(), finished_at = NULL,
+updated_at = NOW()
+WHERE id = $1
+AND status = 'dead'
+RETURNING *;
+
+-- name: DeleteFinishedJobs :execrows
+DELETE FROM jobs
+WHERE status = 'succeeded'
+AND finished_at < sqlc.arg('before')::timestamp;
\ No newline at end of file
@@ -0,0 +1,27 @@
+-- name: CreateLike :execrows
+INSERT INTO likes (user_id, chirp_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: DeleteLike :execrows
+DELETE FROM likes
+WHERE user_id = $1
+AND chirp_id = $2;
+
+-- name: IncrementLikeCount :one
+UPDATE chirps SET like_count = like_count + 1
+WHERE id = $1
+RETURNING user_id;
+
+-- name: DecrementLikeCount :exec
+UPDATE chirps SET like_count = GREATEST(like_count - 1, 0)
+WHERE id = $1;
+
+-- name: ListLikedChirpIDs :many
+SELECT chirp_id FROM likes
+WHERE user_id = sqlc.arg('user_id')
+AND chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
\ No newline at end of file
@@ -0,0 +1,22 @@
+-- name: ListLoginThrottles :many
+SELECT * FROM login_throttles
+WHERE key = ANY(sqlc.arg('keys')::text[]);
+
+-- name: RecordLoginFailure :one
+INSERT INTO login_throttles (key, failures, last_failure_at)
+VALUES (sqlc.arg('key'), 1, sqlc.arg('failed_at'))
+ON CONFLICT (key) DO UPDATE SET
+    failures = CASE
+        WHEN login_throttles.last_failure_at < sqlc.arg('reset_before') THEN 1
+        ELSE login_throttles.failures + 1
+    END,
+    last_failure_at = EXCLUDED.last_failure_at
+RETURNING *;
+
+-- name: ClearLoginThrottle :exec
+DELETE FROM login_throttles
+WHERE key = $1;
+
+-- name: DeleteLoginThrottlesBefore :exec
+DELETE FROM login_throttles
+WHERE last_failure_at < $1;
\ No newline at end of file
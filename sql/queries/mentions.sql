@@ -0,0 +1,21 @@
+-- name: CreateMention :exec
+INSERT INTO mentions (chirp_id, user_id, start_offset, end_offset)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+);
+
+-- name: DeleteChirpMentions :exec
+DELETE FROM mentions
+WHERE chirp_id = $1;
+
+-- name: ListChirpMentionedUserIDs :many
+SELECT DISTINCT user_id FROM mentions
+WHERE chirp_id = $1;
+
+-- name: ListMentionsByChirpIDs :many
+SELECT * FROM mentions
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_id, start_offset;
\ No newline at end of file
@@ -0,0 +1,21 @@
+-- name: CreateRechirp :execrows
+INSERT INTO rechirps (user_id, chirp_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: DeleteRechirp :execrows
+DELETE FROM rechirps
+WHERE user_id = $1
+AND chirp_id = $2;
+
+-- name: IncrementRechirpCount :exec
+UPDATE chirps SET rechirp_count = rechirp_count + 1
+WHERE id = $1;
+
+-- name: DecrementRechirpCount :exec
+UPDATE chirps SET rechirp_count = GREATEST(rechirp_count - 1, 0)
+WHERE id = $1;
\ No newline at end of file
@@ -0,0 +1,28 @@
+-- name: SearchChirps :many
+SELECT sqlc.embed(chirps),
+    ts_rank(to_tsvector('english', chirps.body), websearch_to_tsquery('english', sqlc.arg('query')::text))::float8 AS rank
+FROM chirps
+JOIN users ON users.id = chirps.user_id
+WHERE chirps.deleted_at IS NULL
+AND users.suspended_at IS NULL
+AND to_tsvector('english', chirps.body) @@ websearch_to_tsquery('english', sqlc.arg('query')::text)
+AND (sqlc.narg('author_id')::uuid IS NULL OR chirps.user_id = sqlc.narg('author_id')::uuid)
+AND (sqlc.narg('since')::timestamp IS NULL OR chirps.created_at >= sqlc.narg('since')::timestamp)
+AND (sqlc.narg('until')::timestamp IS NULL OR chirps.created_at < sqlc.narg('until')::timestamp)
+AND (sqlc.narg('after_rank')::float8 IS NULL
+    OR (ts_rank(to_tsvector('english', chirps.body), websearch_to_tsquery('english', sqlc.arg('query')::text))::float8, chirps.created_at, chirps.id)
+        < (sqlc.narg('after_rank')::float8, sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::uuid))
+ORDER BY rank DESC, chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: SearchUsers :many
+SELECT users.*,
+    (SELECT COUNT(*) FROM follows f WHERE f.followee_id = users.id)::bigint AS follower_count,
+    (SELECT COUNT(*) FROM follows f WHERE f.follower_id = users.id)::bigint AS following_count
+FROM users
+WHERE users.suspended_at IS NULL
+AND (users.handle LIKE sqlc.arg('pattern')::text OR lower(users.display_name) LIKE sqlc.arg('pattern')::text)
+AND (sqlc.narg('after_created_at')::timestamp IS NULL
+    OR (users.created_at, users.id) < (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::uuid))
+ORDER BY users.created_at DESC, users.id DESC
+LIMIT sqlc.arg('page_size');
\ No newline at end of file
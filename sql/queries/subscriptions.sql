@@ -0,0 +1,46 @@
+-- name: GetSubscription :one
+SELECT * FROM subscriptions
+WHERE user_id = $1;
+
+-- name: LockSubscription :one
+SELECT * FROM subscriptions
+WHERE user_id = $1
+FOR UPDATE;
+
+-- name: ActivateSubscription :one
+INSERT INTO subscriptions (user_id, created_at, updated_at, plan, status, current_period_end)
+VALUES (
+    $1,
+    NOW(),
+    NOW(),
+    $2,
+    'active',
+    $3
+)
+ON CONFLICT (user_id) DO UPDATE SET plan = EXCLUDED.plan,
+status = 'active',
+current_period_end = EXCLUDED.current_period_end,
+canceled_at = NULL,
+updated_at = NOW()
+RETURNING *;
+
+-- name: SetSubscriptionStatus :one
+UPDATE subscriptions SET status = $2,
+canceled_at = CASE WHEN $2 = 'canceled' THEN NOW() ELSE canceled_at END,
+updated_at = NOW()
+WHERE user_id = $1
+RETURNING *;
+
+-- name: ExpireLapsedSubscriptions :many
+UPDATE subscriptions SET status = 'expired', updated_at = NOW()
+WHERE status <> 'expired'
+AND current_period_end IS NOT NULL
+AND (
+    (status = 'canceled' AND current_period_end <= sqlc.arg('now')::timestamp)
+    OR current_period_end <= sqlc.arg('grace_before')::timestamp
+)
+RETURNING user_id;
+
+-- name: SetChirpyRed :exec
+UPDATE users SET is_chirpy_red = sqlc.arg('is_chirpy_red'), updated_at = NOW()
+WHERE id = ANY(sqlc.arg('ids')::uuid[]);
\ No newline at end of file
@@ -0,0 +1,63 @@
+-- name: UpsertTag :one
+INSERT INTO tags (id, name, created_at)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    NOW()
+)
+ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+RETURNING *;
+
+-- name: CreateChirpTag :exec
+INSERT INTO chirp_tags (chirp_id, tag_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT (chirp_id, tag_id) DO NOTHING;
+
+-- name: DeleteChirpTags :exec
+DELETE FROM chirp_tags
+WHERE chirp_id = $1;
+
+-- name: DeleteChirpTagsExcept :exec
+DELETE FROM chirp_tags
+WHERE chirp_id = sqlc.arg('chirp_id')
+AND tag_id NOT IN (
+    SELECT t.id FROM tags t
+    WHERE t.name = ANY(sqlc.arg('keep')::text[])
+);
+
+-- name: ListTagChirps :many
+SELECT chirps.* FROM chirps
+JOIN chirp_tags ON chirp_tags.chirp_id = chirps.id
+JOIN tags ON tags.id = chirp_tags.tag_id
+WHERE tags.name = sqlc.arg('tag')
+AND chirps.deleted_at IS NULL
+AND (sqlc.narg('after_created_at')::timestamp IS NULL
+    OR (chirps.created_at, chirps.id) < (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::uuid))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: DeleteTrendingTags :exec
+DELETE FROM trending_tags;
+
+-- name: InsertTrendingTags :exec
+INSERT INTO trending_tags (tag_id, score, computed_at)
+SELECT chirp_tags.tag_id,
+    SUM(POWER(0.5, EXTRACT(EPOCH FROM (NOW() - chirp_tags.created_at)) / sqlc.arg('half_life_seconds')::double precision)),
+    NOW()
+FROM chirp_tags
+JOIN chirps ON chirps.id = chirp_tags.chirp_id
+WHERE chirp_tags.created_at > NOW() - make_interval(secs => sqlc.arg('window_seconds')::double precision)
+AND chirps.deleted_at IS NULL
+GROUP BY chirp_tags.tag_id
+ORDER BY 2 DESC
+LIMIT sqlc.arg('max_tags');
+
+-- name: ListTrendingTags :many
+SELECT tags.name, trending_tags.score, trending_tags.computed_at FROM trending_tags
+JOIN tags ON tags.id = trending_tags.tag_id
+ORDER BY trending_tags.score DESC
+LIMIT $1;
\ No newline at end of file
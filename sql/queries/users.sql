@@ -1,11 +1,13 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle, display_name)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4
 )
 RETURNING *;
 
@@ -14,11 +16,51 @@ SELECT * FROM users
 WHERE email = $1;
 
 -- name: UpdateUser :one
-UPDATE users SET email = $2, hashed_password = $3, updated_at = NOW()
+UPDATE users SET email = $2, hashed_password = $3, updated_at = NOW(),
+email_verified_at = CASE WHEN email = $2 THEN email_verified_at ELSE NULL END
 WHERE id = $1
 RETURNING *;
 
--- name: UpgradeToChirpyRed :one
-UPDATE users SET is_chirpy_red = true, updated_at = NOW()
+-- name: UpdateUserPassword :exec
+UPDATE users SET hashed_password = $2, password_reset_required = false, updated_at = NOW()
+WHERE id = $1;
+
+-- name: MarkEmailVerified :execrows
+UPDATE users SET email_verified_at = NOW(), updated_at = NOW()
+WHERE id = $1
+AND email = $2;
+
+-- name: GetUser :one
+SELECT * FROM users
+WHERE id = $1;
+
+-- name: UpdateUserHandle :one
+UPDATE users SET handle = $2, updated_at = NOW()
 WHERE id = $1
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: UpdateUserDisplayName :one
+UPDATE users SET display_name = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: ListUsersByHandles :many
+SELECT * FROM users
+WHERE handle = ANY(sqlc.arg('handles')::text[]);
+
+-- name: LockUser :one
+SELECT * FROM users
+WHERE id = $1
+FOR UPDATE;
+
+-- name: SetTokensValidAfter :exec
+UPDATE users SET tokens_valid_after = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetTokensValidAfter :one
+SELECT tokens_valid_after FROM users
+WHERE id = $1;
+
+-- name: RehashUserPassword :execrows
+UPDATE users SET hashed_password = sqlc.arg('new_hash')
+WHERE id = sqlc.arg('id') AND hashed_password = sqlc.arg('old_hash');
\ No newline at end of file
@@ -0,0 +1,97 @@
+-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (id, created_at, updated_at, user_id, url, secret, event_types)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4
+)
+RETURNING *;
+
+-- name: ListWebhookEndpoints :many
+SELECT * FROM webhook_endpoints
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: CountWebhookEndpoints :one
+SELECT COUNT(*) FROM webhook_endpoints
+WHERE user_id = $1;
+
+-- name: GetWebhookEndpoint :one
+SELECT * FROM webhook_endpoints
+WHERE id = $1;
+
+-- name: DeleteWebhookEndpoint :execrows
+DELETE FROM webhook_endpoints
+WHERE id = $1
+AND user_id = $2;
+
+-- name: EnableWebhookEndpoint :one
+UPDATE webhook_endpoints SET disabled_at = NULL, disabled_reason = NULL,
+consecutive_failures = 0, updated_at = NOW()
+WHERE id = $1
+AND user_id = $2
+RETURNING *;
+
+-- name: RecordWebhookEndpointSuccess :exec
+UPDATE webhook_endpoints SET consecutive_failures = 0
+WHERE id = $1;
+
+-- name: RecordWebhookEndpointFailure :one
+UPDATE webhook_endpoints SET consecutive_failures = consecutive_failures + 1,
+disabled_at = CASE WHEN consecutive_failures + 1 >= sqlc.arg('disable_after')::integer THEN NOW() ELSE disabled_at END,
+disabled_reason = CASE WHEN consecutive_failures + 1 >= sqlc.arg('disable_after')::integer THEN sqlc.arg('reason')::text ELSE disabled_reason END,
+updated_at = NOW()
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: EnqueueWebhookDeliveries :many
+INSERT INTO webhook_deliveries (id, created_at, updated_at, endpoint_id, event_id, event_type, payload, status, next_attempt_at)
+SELECT gen_random_uuid(), NOW(), NOW(), webhook_endpoints.id, sqlc.arg('event_id')::uuid, sqlc.arg('event_type')::text, sqlc.arg('payload')::jsonb, 'pending', NOW()
+FROM webhook_endpoints
+WHERE webhook_endpoints.user_id = sqlc.arg('user_id')::uuid
+AND webhook_endpoints.disabled_at IS NULL
+AND sqlc.arg('event_type')::text = ANY(webhook_endpoints.event_types)
+RETURNING webhook_deliveries.*;
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries
+WHERE id = $1;
+
+-- name: RecordWebhookDeliveryAttempt :exec
+UPDATE webhook_deliveries SET status = $2,
+attempts = attempts + 1,
+next_attempt_at = $3,
+last_attempt_at = NOW(),
+response_status = $4,
+response_body = $5,
+error = $6,
+updated_at = NOW()
+WHERE id = $1;
+
+-- name: FailWebhookDelivery :one
+UPDATE webhook_deliveries SET status = 'failed',
+error = $2,
+updated_at = NOW()
+WHERE id = $1
+AND status = 'pending'
+RETURNING *;
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE endpoint_id = sqlc.arg('endpoint_id')
+AND (sqlc.narg('after_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: RedeliverWebhook :one
+INSERT INTO webhook_deliveries (id, created_at, updated_at, endpoint_id, event_id, event_type, payload, status, next_attempt_at)
+SELECT gen_random_uuid(), NOW(), NOW(), endpoint_id, event_id, event_type, payload, 'pending', NOW()
+FROM webhook_deliveries
+WHERE webhook_deliveries.id = $1
+AND webhook_deliveries.endpoint_id = $2
+RETURNING webhook_deliveries.*;
\ No newline at end of file
@@ -0,0 +1,21 @@
+-- name: RecordWebhookEvent :one
+INSERT INTO webhook_events (id, event_type, payload, received_at, last_received_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    NOW(),
+    NOW()
+)
+ON CONFLICT (id) DO UPDATE SET deliveries = webhook_events.deliveries + 1,
+last_received_at = NOW()
+RETURNING *;
+
+-- name: LockWebhookEvent :one
+SELECT * FROM webhook_events
+WHERE id = $1
+FOR UPDATE;
+
+-- name: SetWebhookEventOutcome :exec
+UPDATE webhook_events SET status = $2, error = $3, processed_at = NOW()
+WHERE id = $1;
\ No newline at end of file
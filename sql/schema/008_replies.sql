@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN in_reply_to UUID REFERENCES chirps(id) ON DELETE SET NULL,
+ADD COLUMN reply_count INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN deleted_at TIMESTAMP;
+CREATE INDEX chirps_in_reply_to_created_at_idx ON chirps (in_reply_to, created_at);
+
+-- +goose Down
+DROP INDEX chirps_in_reply_to_created_at_idx;
+ALTER TABLE chirps
+DROP COLUMN deleted_at,
+DROP COLUMN reply_count,
+DROP COLUMN in_reply_to;
\ No newline at end of file
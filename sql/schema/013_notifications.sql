@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    actor_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID REFERENCES chirps(id) ON DELETE CASCADE,
+    read_at TIMESTAMP
+);
+CREATE INDEX notifications_user_id_created_at_idx ON notifications (user_id, created_at);
+CREATE INDEX notifications_unread_idx ON notifications (user_id, created_at)
+WHERE read_at IS NULL;
+
+-- +goose Down
+DROP TABLE notifications;
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN display_name TEXT;
+CREATE INDEX chirps_body_search_idx ON chirps USING GIN (to_tsvector('english', body));
+CREATE INDEX users_handle_prefix_idx ON users (handle text_pattern_ops);
+CREATE INDEX users_display_name_prefix_idx ON users (lower(display_name) text_pattern_ops);
+
+-- +goose Down
+DROP INDEX users_display_name_prefix_idx;
+DROP INDEX users_handle_prefix_idx;
+DROP INDEX chirps_body_search_idx;
+ALTER TABLE users
+DROP COLUMN display_name;
\ No newline at end of file
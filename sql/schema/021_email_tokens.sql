@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN email_verified_at TIMESTAMP;
+CREATE TABLE email_tokens (
+    token_hash TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose TEXT NOT NULL,
+    email TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP
+);
+CREATE INDEX email_tokens_user_id_idx ON email_tokens (user_id, purpose);
+
+-- +goose Down
+DROP TABLE email_tokens;
+ALTER TABLE users
+DROP COLUMN email_verified_at;
\ No newline at end of file
@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'moderator', 'admin')),
+ADD COLUMN suspended_at TIMESTAMP,
+ADD COLUMN password_reset_required BOOLEAN NOT NULL DEFAULT false;
+CREATE INDEX users_email_prefix_idx ON users (lower(email) text_pattern_ops);
+CREATE TABLE audit_log (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    action TEXT NOT NULL,
+    target_type TEXT NOT NULL,
+    target_id UUID,
+    details JSONB NOT NULL DEFAULT '{}'
+);
+CREATE INDEX audit_log_created_at_idx ON audit_log (created_at DESC, id DESC);
+CREATE INDEX audit_log_target_idx ON audit_log (target_id, created_at DESC);
+
+-- +goose Down
+DROP TABLE audit_log;
+DROP INDEX users_email_prefix_idx;
+ALTER TABLE users
+DROP COLUMN password_reset_required,
+DROP COLUMN suspended_at,
+DROP COLUMN role;
\ No newline at end of file
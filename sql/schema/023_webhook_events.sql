@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE webhook_events (
+    id TEXT PRIMARY KEY,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'received'
+        CHECK (status IN ('received', 'processed', 'ignored', 'failed')),
+    error TEXT,
+    deliveries INTEGER NOT NULL DEFAULT 1,
+    received_at TIMESTAMP NOT NULL,
+    last_received_at TIMESTAMP NOT NULL,
+    processed_at TIMESTAMP
+);
+CREATE INDEX webhook_events_status_idx ON webhook_events (status, received_at DESC);
+
+-- +goose Down
+DROP TABLE webhook_events;
\ No newline at end of file
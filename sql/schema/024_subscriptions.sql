@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE subscriptions (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    plan TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('active', 'past_due', 'canceled', 'expired')),
+    current_period_end TIMESTAMP,
+    canceled_at TIMESTAMP
+);
+CREATE INDEX subscriptions_period_end_idx ON subscriptions (current_period_end)
+WHERE status <> 'expired';
+
+-- Earlier upgrades had no billing period, so they stay active until Polka says otherwise
+INSERT INTO subscriptions (user_id, created_at, updated_at, plan, status)
+SELECT id, updated_at, updated_at, 'red', 'active'
+FROM users
+WHERE is_chirpy_red;
+
+-- +goose Down
+DROP TABLE subscriptions;
\ No newline at end of file
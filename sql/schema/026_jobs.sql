@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE jobs (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    kind TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    unique_key TEXT UNIQUE,
+    status TEXT NOT NULL CHECK (status IN ('pending', 'running', 'succeeded', 'dead')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    run_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP,
+    last_error TEXT,
+    finished_at TIMESTAMP
+);
+CREATE INDEX jobs_due_idx ON jobs (run_at) WHERE status = 'pending';
+CREATE INDEX jobs_lease_idx ON jobs (locked_until) WHERE status = 'running';
+CREATE INDEX jobs_created_at_idx ON jobs (created_at DESC, id DESC);
+
+-- +goose Down
+DROP TABLE jobs;
\ No newline at end of file
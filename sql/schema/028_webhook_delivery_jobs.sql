@@ -0,0 +1,12 @@
+-- +goose Up
+DROP INDEX webhook_deliveries_due_idx;
+INSERT INTO jobs (id, created_at, updated_at, kind, payload, status, max_attempts, run_at)
+SELECT gen_random_uuid(), NOW(), NOW(), 'webhook.deliver', jsonb_build_object('delivery_id', id), 'pending', 8, GREATEST(next_attempt_at, NOW())
+FROM webhook_deliveries
+WHERE status = 'pending';
+
+-- +goose Down
+DELETE FROM jobs
+WHERE kind = 'webhook.deliver';
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at)
+WHERE status = 'pending';
\ No newline at end of file
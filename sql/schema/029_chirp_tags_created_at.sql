@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX chirp_tags_created_at_idx ON chirp_tags (created_at);
+
+-- +goose Down
+DROP INDEX chirp_tags_created_at_idx;
\ No newline at end of file
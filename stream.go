@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"chirpy/internal/database"
+	"chirpy/internal/stream"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Settings for chirp streaming: events are kept for the retention period so clients can
+// resume with Last-Event-ID, and missed events are replayed in pages of streamReplayLimit.
+// IDs are assigned at insert but become visible at commit, so a lower ID can show up after
+// a higher one; catch-up and replay re-read the last streamReorderWindow IDs to cover that.
+const (
+	streamBufferSize        = 64
+	streamHeartbeatInterval = 15 * time.Second
+	streamReplayLimit       = 500
+	streamReorderWindow     = 100
+	streamRetention         = 24 * time.Hour
+	streamPruneInterval     = time.Hour
+	streamListenerPing      = 90 * time.Second
+	streamListenRetryMin    = time.Second
+	streamListenRetryMax    = time.Minute
+)
+
+// Method to listen for chirp events from every server instance via Postgres LISTEN/NOTIFY
+// and publish them to the local hub until the context is cancelled
+func (cfg *apiConfig) runStreamListener(ctx context.Context, dbURL string) {
+	listener := pq.NewListener(dbURL, 10*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Error in chirp event listener: %s", err)
+		}
+	})
+	defer listener.Close()
+
+	// Keep trying to listen with backoff, so a failure at startup doesn't turn streaming
+	// off until the next restart
+	retryDelay := streamListenRetryMin
+	for {
+		err := listener.Listen(database.ChirpEventsChannel)
+		if err == nil || errors.Is(err, pq.ErrChannelAlreadyOpen) {
+			break
+		}
+		log.Printf("Error listening for chirp events, retrying in %s: %s", retryDelay, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryDelay):
+		}
+		retryDelay = min(retryDelay*2, streamListenRetryMax)
+	}
+
+	pingTicker := time.NewTicker(streamListenerPing)
+	defer pingTicker.Stop()
+
+	// Start from the latest logged event, so a reconnect before the first notification
+	// still catches up on what was missed
+	published := newPublishedEvents()
+	lastID, err := cfg.db.GetLatestChirpEventID(ctx)
+	if err != nil {
+		log.Printf("Error retreiving latest chirp event: %s", err)
+	}
+	published.lastID = lastID
+	published.floor = lastID
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case n := <-listener.Notify:
+			// A nil notification means the connection was re-established and
+			// notifications may have been missed, so catch up from the event log
+			if n == nil {
+				cfg.publishChirpEventsAfter(ctx, published)
+				continue
+			}
+			id, err := strconv.ParseInt(n.Extra, 10, 64)
+			if err != nil {
+				log.Printf("Invalid chirp event notification %q: %s", n.Extra, err)
+				continue
+			}
+			if published.seen(id) {
+				continue
+			}
+			dbEvent, err := cfg.db.GetChirpEvent(ctx, id)
+			if err != nil {
+				log.Printf("Error retreiving chirp event %d: %s", id, err)
+				continue
+			}
+			events, err := cfg.streamEventsFromDB(ctx, []database.ChirpEvent{dbEvent})
+			if err != nil {
+				log.Printf("Error building chirp event %d: %s", id, err)
+				continue
+			}
+			for _, event := range events {
+				cfg.stream.Publish(event)
+			}
+			published.add(id)
+
+		case <-pingTicker.C:
+			go listener.Ping()
+		}
+	}
+}
+
+// Struct to track which chirp events the listener has published: every ID up to floor
+// counts as published, and IDs inside the reorder window above it are kept in a set
+type publishedEvents struct {
+	floor  int64
+	lastID int64
+	ids    map[int64]struct{}
+}
+
+// Function to create an empty set of published chirp events
+func newPublishedEvents() *publishedEvents {
+	return &publishedEvents{ids: map[int64]struct{}{}}
+}
+
+// Method to report whether an event has already been published
+func (p *publishedEvents) seen(id int64) bool {
+	if id <= p.floor {
+		return true
+	}
+	_, ok := p.ids[id]
+	return ok
+}
+
+// Method to record a published event and forget IDs that fell out of the reorder window
+func (p *publishedEvents) add(id int64) {
+	p.ids[id] = struct{}{}
+	p.lastID = max(p.lastID, id)
+	p.floor = max(p.floor, p.lastID-streamReorderWindow)
+	for seenID := range p.ids {
+		if seenID <= p.floor {
+			delete(p.ids, seenID)
+		}
+	}
+}
+
+// Method to delete chirp events older than the retention period
+func (cfg *apiConfig) runChirpEventPruneJob(ctx context.Context, _ struct{}) error {
+	return cfg.db.DeleteChirpEventsBefore(ctx, time.Now().Add(-streamRetention))
+}
+
+// Method to publish every logged chirp event the listener hasn't published yet, starting
+// a reorder window behind the latest one to pick up events that committed out of order
+func (cfg *apiConfig) publishChirpEventsAfter(ctx context.Context, published *publishedEvents) {
+	afterID := max(published.lastID-streamReorderWindow, 0)
+	for {
+		dbEvents, err := cfg.db.ListChirpEventsAfter(ctx, database.ListChirpEventsAfterParams{
+			AfterID:  afterID,
+			PageSize: streamReplayLimit,
+		})
+		if err != nil {
+			log.Printf("Error retreiving missed chirp events: %s", err)
+			return
+		}
+		if len(dbEvents) == 0 {
+			return
+		}
+		missed := []database.ChirpEvent{}
+		for _, dbEvent := range dbEvents {
+			if !published.seen(dbEvent.ID) {
+				missed = append(missed, dbEvent)
+			}
+		}
+		events, err := cfg.streamEventsFromDB(ctx, missed)
+		if err != nil {
+			log.Printf("Error building missed chirp events: %s", err)
+			return
+		}
+		for _, event := range events {
+			cfg.stream.Publish(event)
+		}
+		for _, dbEvent := range missed {
+			published.add(dbEvent.ID)
+		}
+		afterID = dbEvents[len(dbEvents)-1].ID
+	}
+}
+
+// Method to turn logged chirp events into stream events. Created events carry the full
+// chirp; events for chirps that no longer exist are skipped since a delete event follows.
+func (cfg *apiConfig) streamEventsFromDB(ctx context.Context, dbEvents []database.ChirpEvent) ([]stream.Event, error) {
+
+	// Struct for the data of a deleted chirp event
+	type deletedChirp struct {
+		ID     uuid.UUID `json:"id"`
+		UserID uuid.UUID `json:"user_id"`
+	}
+
+	// Retreive chirps for created events in one query
+	chirpIDs := []uuid.UUID{}
+	for _, dbEvent := range dbEvents {
+		if dbEvent.Type == database.ChirpEventCreated {
+			chirpIDs = append(chirpIDs, dbEvent.ChirpID)
+		}
+	}
+	chirps := []Chirp{}
+	if len(chirpIDs) > 0 {
+		dbChirps, err := cfg.db.ListChirpsByIDs(ctx, chirpIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, dbChirp := range dbChirps {
+			chirps = append(chirps, chirpFromDB(dbChirp))
+		}
+		err = cfg.attachMentions(ctx, chirps)
+		if err != nil {
+			return nil, err
+		}
+	}
+	chirpsByID := make(map[uuid.UUID]Chirp, len(chirps))
+	for _, chirp := range chirps {
+		chirpsByID[chirp.ID] = chirp
+	}
+
+	events := []stream.Event{}
+	for _, dbEvent := range dbEvents {
+		var data []byte
+		var err error
+		switch dbEvent.Type {
+		case database.ChirpEventCreated:
+			chirp, ok := chirpsByID[dbEvent.ChirpID]
+			if !ok {
+				continue
+			}
+			data, err = json.Marshal(chirp)
+		case database.ChirpEventDeleted:
+			data, err = json.Marshal(deletedChirp{
+				ID:     dbEvent.ChirpID,
+				UserID: dbEvent.UserID,
+			})
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, stream.Event{
+			ID:     dbEvent.ID,
+			Type:   dbEvent.Type,
+			UserID: dbEvent.UserID,
+			Data:   data,
+		})
+	}
+	return events, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"chirpy/internal/entitlements"
+
+	"github.com/google/uuid"
+)
+
+// Lapsed subscriptions are expired on this interval. Active and past due subscriptions
+// keep Chirpy Red for a grace period after their period ends, in case a renewal arrives late.
+const (
+	subscriptionExpiryInterval = 10 * time.Minute
+	subscriptionGracePeriod    = 3 * 24 * time.Hour
+)
+
+// Struct to contain a user's Chirpy Red subscription, shown only to the user themself
+type Subscription struct {
+	Plan             string     `json:"plan"`
+	Status           string     `json:"status"`
+	CurrentPeriodEnd *time.Time `json:"current_period_end"`
+}
+
+// Method to get a user's subscription for a response. Users who never subscribed get nil.
+func (cfg *apiConfig) subscriptionFor(ctx context.Context, userID uuid.UUID) (*Subscription, error) {
+	dbSubscription, err := cfg.db.GetSubscription(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	subscription := &Subscription{
+		Plan:   dbSubscription.Plan,
+		Status: dbSubscription.Status,
+	}
+	if dbSubscription.CurrentPeriodEnd.Valid {
+		subscription.CurrentPeriodEnd = &dbSubscription.CurrentPeriodEnd.Time
+	}
+	return subscription, nil
+}
+
+// Method to get the plan limits a user is entitled to by their subscription
+func (cfg *apiConfig) limitsFor(ctx context.Context, userID uuid.UUID) (entitlements.Limits, error) {
+	dbSubscription, err := cfg.db.GetSubscription(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return cfg.plans.For(nil), nil
+	}
+	if err != nil {
+		return entitlements.Limits{}, err
+	}
+	return cfg.plans.For(&entitlements.Subscription{
+		Plan:   dbSubscription.Plan,
+		Status: dbSubscription.Status,
+	}), nil
+}
+
+// Method to expire lapsed subscriptions
+func (cfg *apiConfig) runSubscriptionExpiryJob(ctx context.Context, _ struct{}) error {
+	expired, err := cfg.db.ExpireLapsedSubscriptionsTx(ctx, time.Now().UTC(), subscriptionGracePeriod)
+	if err != nil {
+		return err
+	}
+	if expired > 0 {
+		log.Printf("Expired %d lapsed subscriptions", expired)
+	}
+	return nil
+}
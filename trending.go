@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"chirpy/internal/database"
+)
+
+// Settings for trending tag computation: tags used within the window are scored with
+// exponential decay, so a use loses half its weight every half-life
+const (
+	trendingRefreshInterval = 5 * time.Minute
+	trendingWindow          = 24 * time.Hour
+	trendingHalfLife        = 6 * time.Hour
+	trendingMaxTags         = 50
+)
+
+// Method to recompute trending tags
+func (cfg *apiConfig) runTrendingJob(ctx context.Context, _ struct{}) error {
+	return cfg.db.RefreshTrendingTagsTx(ctx, database.InsertTrendingTagsParams{
+		HalfLifeSeconds: trendingHalfLife.Seconds(),
+		WindowSeconds:   trendingWindow.Seconds(),
+		MaxTags:         trendingMaxTags,
+	})
+}
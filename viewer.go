@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+
+	"chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Method to fill in per-request chirp details: mention entities and, for authenticated
+// viewers, the liked_by_me flag
+func (cfg *apiConfig) decorateChirps(ctx context.Context, viewerID uuid.NullUUID, chirps []Chirp) error {
+	err := cfg.attachMentions(ctx, chirps)
+	if err != nil {
+		return err
+	}
+	return cfg.markLikedByMe(ctx, viewerID, chirps)
+}
+
+// Method to render stored mentions as structured entities on chirps
+func (cfg *apiConfig) attachMentions(ctx context.Context, chirps []Chirp) error {
+	if len(chirps) == 0 {
+		return nil
+	}
+
+	chirpIDs := make([]uuid.UUID, 0, len(chirps))
+	for _, chirp := range chirps {
+		chirpIDs = append(chirpIDs, chirp.ID)
+	}
+
+	dbMentions, err := cfg.db.ListMentionsByChirpIDs(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+	mentions := make(map[uuid.UUID][]database.Mention, len(chirps))
+	for _, dbMention := range dbMentions {
+		mentions[dbMention.ChirpID] = append(mentions[dbMention.ChirpID], dbMention)
+	}
+
+	// Handle text is taken from the body so it matches what was written
+	for i := range chirps {
+		body := []rune(chirps[i].Body)
+		for _, dbMention := range mentions[chirps[i].ID] {
+			if int(dbMention.EndOffset) > len(body) {
+				continue
+			}
+			chirps[i].Entities.Mentions = append(chirps[i].Entities.Mentions, MentionEntity{
+				UserID: dbMention.UserID,
+				Handle: string(body[dbMention.StartOffset+1 : dbMention.EndOffset]),
+				Start:  dbMention.StartOffset,
+				End:    dbMention.EndOffset,
+			})
+		}
+	}
+	return nil
+}
+
+// Method to set the liked_by_me flag on chirps for the viewing user
+func (cfg *apiConfig) markLikedByMe(ctx context.Context, viewerID uuid.NullUUID, chirps []Chirp) error {
+
+	// Flag is only included for authenticated requests
+	if !viewerID.Valid || len(chirps) == 0 {
+		return nil
+	}
+
+	chirpIDs := make([]uuid.UUID, 0, len(chirps))
+	for _, chirp := range chirps {
+		chirpIDs = append(chirpIDs, chirp.ID)
+	}
+
+	likedIDs, err := cfg.db.ListLikedChirpIDs(ctx, database.ListLikedChirpIDsParams{
+		UserID:   viewerID.UUID,
+		ChirpIds: chirpIDs,
+	})
+	if err != nil {
+		return err
+	}
+	liked := make(map[uuid.UUID]struct{}, len(likedIDs))
+	for _, id := range likedIDs {
+		liked[id] = struct{}{}
+	}
+
+	for i := range chirps {
+		_, ok := liked[chirps[i].ID]
+		chirps[i].LikedByMe = &ok
+	}
+	return nil
+}
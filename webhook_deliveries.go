@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"chirpy/internal/database"
+	"chirpy/internal/jobs"
+	"chirpy/internal/webhook"
+)
+
+// Timeout for a single delivery attempt
+const webhookSendTimeout = 10 * time.Second
+
+// Endpoints are disabled once this many deliveries in a row have failed every attempt
+const webhookDisableAfter = 5
+
+// errWebhookEndpointDisabled is returned for deliveries to an endpoint that was disabled
+// after they were queued
+var errWebhookEndpointDisabled = errors.New("webhook endpoint is disabled")
+
+// Method to send one queued delivery and record the attempt. Failures are returned so the
+// job queue retries them with its backoff; once the job runs out of attempts it's
+// dead-lettered and failWebhookDelivery gives the delivery up. Deliveries that were
+// deleted or already succeeded are skipped, and ones to a disabled endpoint are given up
+// on straight away.
+func (cfg *apiConfig) runWebhookDeliveryJob(ctx context.Context, payload database.WebhookDeliveryJob) error {
+	delivery, err := cfg.db.GetWebhookDelivery(ctx, payload.DeliveryID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if delivery.Status == database.WebhookDeliveryStatusSucceeded {
+		return nil
+	}
+
+	endpoint, err := cfg.db.GetWebhookEndpoint(ctx, delivery.EndpointID)
+	if err != nil {
+		return err
+	}
+	if endpoint.DisabledAt.Valid {
+		return jobs.Permanent(errWebhookEndpointDisabled)
+	}
+
+	result := cfg.webhooks.Send(ctx, endpoint.Url, endpoint.Secret, webhook.Delivery{
+		ID:        delivery.ID.String(),
+		EventType: delivery.EventType,
+		Payload:   delivery.Payload,
+	})
+
+	// Record the attempt. A failed delivery stays pending until its job is dead-lettered,
+	// showing roughly when the queue will try it again.
+	now := time.Now().UTC()
+	arg := database.RecordWebhookDeliveryAttemptParams{
+		ID:            delivery.ID,
+		Status:        database.WebhookDeliveryStatusSucceeded,
+		NextAttemptAt: now,
+	}
+	if result.StatusCode != 0 {
+		arg.ResponseStatus = sql.NullInt32{Int32: int32(result.StatusCode), Valid: true}
+		arg.ResponseBody = sql.NullString{String: result.Body, Valid: true}
+	}
+	if !result.OK() {
+		arg.Status = database.WebhookDeliveryStatusPending
+		arg.NextAttemptAt = now.Add(jobs.Backoff(delivery.Attempts + 1))
+		arg.Error = sql.NullString{String: result.Err.Error(), Valid: true}
+	}
+	err = cfg.db.RecordWebhookDeliveryAttempt(ctx, arg)
+	if err != nil {
+		return err
+	}
+	if !result.OK() {
+		return result.Err
+	}
+
+	// A successful delivery resets the endpoint's run of failures
+	err = cfg.db.RecordWebhookEndpointSuccess(ctx, endpoint.ID)
+	if err != nil {
+		log.Printf("Error updating webhook endpoint %s: %s", endpoint.ID, err)
+	}
+	return nil
+}
+
+// Method run when a delivery job is dead-lettered, marking the delivery failed and, unless
+// its endpoint was already disabled, counting it against the endpoint, which is disabled
+// after repeated failed deliveries
+func (cfg *apiConfig) failWebhookDelivery(ctx context.Context, payload database.WebhookDeliveryJob, cause error) error {
+	delivery, err := cfg.db.FailWebhookDelivery(ctx, database.FailWebhookDeliveryParams{
+		ID:    payload.DeliveryID,
+		Error: sql.NullString{String: cause.Error(), Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if errors.Is(cause, errWebhookEndpointDisabled) {
+		return nil
+	}
+
+	endpoint, err := cfg.db.RecordWebhookEndpointFailure(ctx, database.RecordWebhookEndpointFailureParams{
+		DisableAfter: webhookDisableAfter,
+		Reason:       "Too many failed deliveries",
+		ID:           delivery.EndpointID,
+	})
+	if err != nil {
+		return err
+	}
+	if endpoint.DisabledAt.Valid && endpoint.ConsecutiveFailures == webhookDisableAfter {
+		log.Printf("Disabled webhook endpoint %s after %d failed deliveries", endpoint.ID, webhookDisableAfter)
+	}
+	return nil
+}